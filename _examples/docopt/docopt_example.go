@@ -104,13 +104,9 @@ func main() {
 		CachePath: "/tmp/cache",
 	}
 
-	// Update defaults from env
-	if err := env.Bind(opts); err != nil {
-		log.Fatal(err)
-	}
-
-	// Update config from docopt options
-	e := &doptenv{dopts}
+	// Update defaults from docopt options, falling back to
+	// environment variables for options that weren't passed
+	e := env.Chain(&doptenv{dopts}, env.System)
 	if err := env.Bind(opts, e); err != nil {
 		log.Fatal(err)
 	}
@@ -7,11 +7,13 @@ import (
 	"encoding"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/url"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 )
 
@@ -25,15 +27,6 @@ var (
 // function that can parse a string into a type's native values.
 type parseFunc func(s string) (interface{}, error)
 
-// return function from kindParsers/typeParsers appropriate for fieldType.
-func getParseFunc(fieldType reflect.Type) (fun parseFunc, ok bool) {
-	if fun, ok = typeParsers[fieldType]; ok {
-		return
-	}
-	fun, ok = kindParsers[fieldType.Kind()]
-	return
-}
-
 // Functions to parse strings into type-appropriate values.
 var (
 	kindParsers = map[reflect.Kind]parseFunc{
@@ -88,7 +81,17 @@ var (
 		reflect.Float64: func(s string) (interface{}, error) {
 			return strconv.ParseFloat(s, 64)
 		},
+		reflect.Complex64: func(s string) (interface{}, error) {
+			c, err := parseComplex(s, 64)
+			return complex64(c), err
+		},
+		reflect.Complex128: func(s string) (interface{}, error) {
+			return parseComplex(s, 128)
+		},
 	}
+	// Parsers for specific types. These take precedence over kindParsers
+	// and encoding.TextUnmarshaler. Pointer types are dereferenced if no
+	// parser is registered for the pointer type itself.
 	typeParsers = map[reflect.Type]parseFunc{
 		reflect.TypeOf(url.URL{}): func(s string) (interface{}, error) {
 			u, err := url.Parse(s)
@@ -104,9 +107,34 @@ var (
 			}
 			return d, nil
 		},
+		reflect.TypeOf(&big.Int{}): func(s string) (interface{}, error) {
+			return parseBigInt(s)
+		},
+		reflect.TypeOf(&big.Float{}): func(s string) (interface{}, error) {
+			return parseBigFloat(s)
+		},
+		reflect.TypeOf(&big.Rat{}): func(s string) (interface{}, error) {
+			return parseBigRat(s)
+		},
+		reflect.TypeOf(&regexp.Regexp{}): func(s string) (interface{}, error) {
+			re, err := regexp.Compile(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regexp %q: %w", s, err)
+			}
+			return re, nil
+		},
+		reflect.TypeOf(&template.Template{}): func(s string) (interface{}, error) {
+			t, err := template.New("").Parse(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template %q: %w", s, err)
+			}
+			return t, nil
+		},
 	}
 )
 
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
 // ErrUnsupported is returned by Bind if a field of an unsupported type is tagged for binding.
 // Unsupported fields that are not tagged are ignored.
 type ErrUnsupported string
@@ -116,30 +144,63 @@ func (err ErrUnsupported) Error() string {
 	return "unsupported type: " + string(err)
 }
 
+// MissingVarsError is returned by Bind if variables for fields tagged
+// `env:",required"` are unset or empty.
+type MissingVarsError struct {
+	Vars []string
+}
+
+// Error implements error.
+func (err *MissingVarsError) Error() string {
+	if len(err.Vars) == 1 {
+		return "missing required variable: " + err.Vars[0]
+	}
+	return "missing required variables: " + strings.Join(err.Vars, ", ")
+}
+
 // Bind populates the fields of a struct from environment variables.
 //
 // Variables are mapped to fields using `env:"..."` tags, and the
 // struct is populated by passing it to Bind(). Unset or empty
-// environment variables are ignored.
+// environment variables are ignored, unless the field is tagged
+// `env:",required"`, in which case Bind returns a *MissingVarsError.
 //
 // Untagged fields have a default environment variable assigned to
 // them. See VarName() for details of how names are generated.
 //
+// Fields of nested structs are read from variables with the same names
+// as if they were fields of the outer struct. A nil pointer to a nested
+// struct is set to a new struct if any of its variables are set, and
+// left nil otherwise, in which case its required fields aren't required.
+//
 // Bind accepts an optional Env argument. If provided, values will
-// be looked up via that Env instead of the program's environment.
+// be looked up via that Env instead of the default Env (the program's
+// environment unless changed with SetDefault).
 func Bind(v interface{}, env ...Env) error {
 	var e Env
 	if len(env) > 0 {
 		e = env[0]
-	} else {
-		e = &systemEnv{}
 	}
 
 	return bind(v, e)
 }
 
+// BindOption is a configuration option to BindWith.
+type BindOption func(b *binder)
+
+// BindWith populates the fields of a struct from Env like Bind, but also
+// accepts options. If env is nil, values are read from the program's
+// environment.
+func BindWith(v interface{}, env Env, opt ...BindOption) error {
+	return bind(v, env, opt...)
+}
+
 // populate struct v from Env.
-func bind(v interface{}, env Env) error {
+func bind(v interface{}, env Env, opt ...BindOption) error {
+	if env == nil {
+		env = defaultEnvironment()
+	}
+
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr {
 		return ErrNotStructPtr
@@ -148,12 +209,58 @@ func bind(v interface{}, env Env) error {
 	if rv.Kind() != reflect.Struct {
 		return ErrNotStructPtr
 	}
-	return populate(rv, env)
+
+	b := &binder{env: env, seen: map[string]bool{}, active: map[reflect.Type]bool{}}
+	for _, o := range opt {
+		o(b)
+	}
+	if b.report != nil {
+		*b.report = nil
+	}
+	if err := b.populate(rv, "", ""); err != nil {
+		return err
+	}
+	if len(b.missing) > 0 {
+		return &MissingVarsError{Vars: b.missing}
+	}
+	return b.checkUnknown()
+}
+
+// binder populates structs from an Env.
+type binder struct {
+	env     Env
+	found   int                   // number of non-empty variables read
+	seen    map[string]bool       // names of all variables looked up
+	missing []string              // names of required variables that are unset
+	active  map[reflect.Type]bool // struct types being populated
+
+	strict       bool
+	strictPrefix string
+	report       *Report
+}
+
+// lookup returns the value of variable key and the Env that supplied it.
+// It returns an error if the Env is a FallibleEnv and the lookup fails.
+func (b *binder) lookup(key string) (value string, source Env, err error) {
+	b.seen[key] = true
+	value, source, _, err = lookupSource(b.env, key)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", key, err)
+	}
+	if value != "" {
+		b.found++
+	}
+	return value, source, nil
 }
 
-// set Value rv from Env.
-func populate(rv reflect.Value, env Env) error {
+// set Value rv from Env. The names of all variables are prefixed with
+// prefix. path is the path of rv from the root struct.
+func (b *binder) populate(rv reflect.Value, prefix, path string) error {
 	rvType := rv.Type()
+	if !b.active[rvType] {
+		b.active[rvType] = true
+		defer delete(b.active, rvType)
+	}
 
 	for i := 0; i < rvType.NumField(); i++ {
 		fieldVal := rv.Field(i)
@@ -161,151 +268,609 @@ func populate(rv reflect.Value, env Env) error {
 			continue
 		}
 
-		// pointer fieldVal
-		if fieldVal.Kind() == reflect.Ptr && !fieldVal.IsNil() {
-			if err := bind(fieldVal.Interface(), env); err != nil {
+		field := rvType.Field(i)
+		fieldPath := joinPath(path, field.Name)
+
+		// pointer to nested struct
+		if fieldVal.Kind() == reflect.Ptr && isNested(fieldVal.Type()) {
+			if !fieldVal.IsNil() {
+				if err := b.populate(fieldVal.Elem(), prefix, fieldPath); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// don't allocate a type that is already being populated, or
+			// recursive types, e.g. a linked list, never terminate
+			if b.active[fieldVal.Type().Elem()] {
+				continue
+			}
+
+			// populate a new struct, which is only kept if any of its
+			// variables are set
+			var (
+				p                        = reflect.New(fieldVal.Type().Elem())
+				found, recorded, missing = b.found, b.recorded(), len(b.missing)
+			)
+			if err := b.populate(p.Elem(), prefix, fieldPath); err != nil {
 				return err
 			}
+			if b.found > found {
+				fieldVal.Set(p)
+			} else {
+				b.truncate(recorded)
+				b.missing = b.missing[:missing]
+			}
 			continue
 		}
 
 		// embedded struct
 		if fieldVal.Kind() == reflect.Struct && fieldVal.CanAddr() && fieldVal.Type().Name() == "" {
-			if err := bind(fieldVal.Addr().Interface(), env); err != nil {
+			if err := b.populate(fieldVal, prefix, fieldPath); err != nil {
 				return err
 			}
 			continue
 		}
 
-		field := rvType.Field(i)
 		key := getFieldKey(field)
 		if key == "-" {
 			continue
 		}
-		value, _ := env.Lookup(key)
+		key = prefix + key
+
+		if isStructSlice(fieldVal.Type()) {
+			if err := b.populateSlice(fieldVal, key, fieldPath); err != nil {
+				return err
+			}
+			continue
+		}
 
+		if isStructMap(fieldVal.Type()) {
+			if err := b.populateMap(fieldVal, key, fieldPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, source, err := b.lookup(key)
+		if err != nil {
+			return err
+		}
 		if value == "" {
-			if fieldVal.Kind() == reflect.Struct {
-				if err := populate(fieldVal, env); err != nil {
+			if fieldVal.Kind() == reflect.Struct && isNested(fieldVal.Type()) {
+				if err := b.populate(fieldVal, prefix, fieldPath); err != nil {
 					return err
 				}
+				continue
+			}
+			if hasOption(field, "required") {
+				b.missing = append(b.missing, key)
 			}
+			b.record(fieldVal, field, fieldPath, key, "", nil)
 			continue
 		}
 		if err := setField(fieldVal, field, value); err != nil {
+			if isSecret(field) {
+				return &ParseError{Var: key, Err: secretError(field)}
+			}
+			return err
+		}
+		b.record(fieldVal, field, fieldPath, key, value, source)
+	}
+
+	return nil
+}
+
+// populateSlice populates a slice of structs from indexed variables,
+// e.g. KEY_0_FIELD, KEY_1_FIELD. If KEY_COUNT is set, it specifies the
+// number of items. Otherwise, items are read until one is missing.
+//
+// Existing items are used as defaults for the corresponding new items.
+// If no variables are set, the slice is left unchanged.
+func (b *binder) populateSlice(rv reflect.Value, key, path string) error {
+	count := -1
+	s, _, err := b.lookup(key + "_COUNT")
+	if err != nil {
+		return err
+	}
+	if s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid item count %s=%q", key+"_COUNT", s)
+		}
+		count = n
+	}
+
+	var (
+		itemType   = rv.Type().Elem()
+		structType = itemType
+		values     = reflect.MakeSlice(rv.Type(), 0, rv.Len())
+	)
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	for i := 0; count < 0 || i < count; i++ {
+		item := reflect.New(structType)
+		if i < rv.Len() {
+			if v := reflect.Indirect(rv.Index(i)); v.IsValid() {
+				item.Elem().Set(v)
+			}
+		}
+
+		found, recorded, missing := b.found, b.recorded(), len(b.missing)
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if err := b.populate(item.Elem(), fmt.Sprintf("%s_%d_", key, i), itemPath); err != nil {
 			return err
 		}
+		if count < 0 && b.found == found {
+			b.truncate(recorded)
+			b.missing = b.missing[:missing]
+			break
+		}
+
+		if itemType.Kind() != reflect.Ptr {
+			item = item.Elem()
+		}
+		values = reflect.Append(values, item)
+	}
+
+	if values.Len() > 0 || count >= 0 {
+		rv.Set(values)
+	}
+	return nil
+}
+
+// populateMap populates a map of structs from variables whose names
+// contain the map key, e.g. KEY_<name>_FIELD. The map keys are
+// discovered by enumerating the Env's variables, so if the Env does not
+// implement Lister, the map is left unchanged.
+//
+// Existing values are used as defaults for the corresponding new values.
+func (b *binder) populateMap(rv reflect.Value, key, path string) error {
+	var (
+		prefix     = key + "_"
+		elemType   = rv.Type().Elem()
+		structType = elemType
+		names      []string
+		seen       = map[string]bool{}
+	)
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	fields := varNames(structType, map[reflect.Type]bool{})
+	for _, k := range Keys(b.env, prefix) {
+		if name := mapKeyName(k[len(prefix):], fields); name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
 	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rv.Type()))
+	}
+	for _, name := range names {
+		mapKey := reflect.ValueOf(name).Convert(rv.Type().Key())
+		item := reflect.New(structType)
+		if v := reflect.Indirect(rv.MapIndex(mapKey)); v.IsValid() {
+			item.Elem().Set(v)
+		}
+
+		itemPath := fmt.Sprintf("%s[%s]", path, name)
+		if err := b.populate(item.Elem(), prefix+name+"_", itemPath); err != nil {
+			return err
+		}
 
+		if elemType.Kind() != reflect.Ptr {
+			item = item.Elem()
+		}
+		rv.SetMapIndex(mapKey, item)
+	}
 	return nil
 }
 
+// mapKeyName extracts a map key from a variable name (minus the map's
+// prefix) by matching it against the names of the struct's fields.
+// If several fields match, the shortest key wins, so that variables of
+// nested slices and maps aren't mistaken for fields of the struct. It
+// returns an empty string if the name matches no field.
+func mapKeyName(name string, fields []string) string {
+	var key string
+	for _, f := range fields {
+		var s string
+		if strings.HasSuffix(f, "_") { // prefix of a nested slice or map
+			if i := strings.Index(name, "_"+f); i > 0 {
+				s = name[:i]
+			}
+		} else if strings.HasSuffix(name, "_"+f) && len(name) > len(f)+1 {
+			s = name[:len(name)-len(f)-1]
+		}
+		if s != "" && (key == "" || len(s) < len(key)) {
+			key = s
+		}
+	}
+	return key
+}
+
+// varNames returns the names of the variables that populate the fields
+// of struct type typ. The names of slices and maps of structs end with
+// "_", as they are the prefixes of their items' variables.
+func varNames(typ reflect.Type, seen map[reflect.Type]bool) []string {
+	var names []string
+	walkVars(reflect.Value{}, typ, "", seen, func(key string, _ reflect.StructField, _ reflect.Value, kind varKind) {
+		if kind != fieldVar {
+			key += "_"
+		}
+		names = append(names, key)
+	})
+	return names
+}
+
+// varKind is the kind of variable passed to a walkVars callback.
+type varKind int
+
+const (
+	fieldVar varKind = iota // variable read into a field
+	sliceVar                // KEY of a slice of structs read from KEY_COUNT, KEY_<n>_*
+	mapVar                  // KEY of a map of structs read from KEY_<name>_*
+)
+
+// walkVars calls fun for each variable that Bind reads into a struct of type
+// typ, with the same naming rules as binder.populate. rv is a value of typ,
+// or an invalid Value if there is none (e.g. for items of slices), and fun
+// receives the field's value or an invalid Value.
+//
+// Nested structs, including pointers to them, are walked, but the items of
+// slices and maps of structs are not: fun is called for the slice or map
+// instead. seen holds the types being walked and stops recursion.
+func walkVars(rv reflect.Value, typ reflect.Type, prefix string, seen map[reflect.Type]bool,
+	fun func(key string, field reflect.StructField, rv reflect.Value, kind varKind)) {
+	if seen[typ] {
+		return
+	}
+	seen[typ] = true
+	defer delete(seen, typ)
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		var fieldVal reflect.Value
+		if rv.IsValid() {
+			fieldVal = rv.Field(i)
+		}
+
+		// pointer to nested struct
+		if field.Type.Kind() == reflect.Ptr && isNested(field.Type) {
+			if fieldVal.IsValid() {
+				fieldVal = fieldVal.Elem() // invalid if nil
+			}
+			walkVars(fieldVal, field.Type.Elem(), prefix, seen, fun)
+			continue
+		}
+
+		// embedded struct
+		if field.Type.Kind() == reflect.Struct && field.Type.Name() == "" {
+			walkVars(fieldVal, field.Type, prefix, seen, fun)
+			continue
+		}
+
+		key := getFieldKey(field)
+		if key == "-" {
+			continue
+		}
+		key = prefix + key
+
+		switch {
+		case isStructSlice(field.Type):
+			fun(key, field, fieldVal, sliceVar)
+		case isStructMap(field.Type):
+			fun(key, field, fieldVal, mapVar)
+		case field.Type.Kind() == reflect.Struct && isNested(field.Type):
+			walkVars(fieldVal, field.Type, prefix, seen, fun)
+		default:
+			fun(key, field, fieldVal, fieldVar)
+		}
+	}
+}
+
+// isStructMap returns true if typ is a map of string keys to nested
+// structs (or pointers to structs).
+func isStructMap(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String && isNested(typ.Elem())
+}
+
+// isStructSlice returns true if typ is a slice of nested structs
+// (or pointers to structs).
+func isStructSlice(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice && isNested(typ.Elem())
+}
+
+// isNested returns true if typ is a struct (or pointer to a struct) that
+// should be populated field by field, not parsed from a single variable.
+func isNested(typ reflect.Type) bool {
+	if hasParser(typ) {
+		return false
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct
+}
+
+// hasParser returns true if parseValue can parse values of type typ.
+func hasParser(typ reflect.Type) bool {
+	if _, ok := lookupParser(typ); ok {
+		return true
+	}
+	if typ.Kind() == reflect.Ptr {
+		return hasParser(typ.Elem())
+	}
+	if _, ok := lookupParser(reflect.PtrTo(typ)); ok {
+		return true
+	}
+	if reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		return true
+	}
+	_, ok := kindParsers[typ.Kind()]
+	return ok
+}
+
 func getFieldKey(field reflect.StructField) string {
-	key := field.Tag.Get("env")
+	key, _ := parseTag(field)
 	if key == "" {
 		key = VarName(field.Name)
 	}
 	return key
 }
 
+// parseTag returns the variable name and options from a field's
+// `env:"NAME,option,..."` tag.
+func parseTag(field reflect.StructField) (name string, opts []string) {
+	parts := strings.Split(field.Tag.Get("env"), ",")
+	return parts[0], parts[1:]
+}
+
+// hasOption returns true if option opt is set in field's `env:"..."` tag.
+func hasOption(field reflect.StructField, opt string) bool {
+	_, opts := parseTag(field)
+	for _, s := range opts {
+		if s == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// joinPath appends a field name to a path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
 // populate Value rv with value parsed from string.
 func setField(rv reflect.Value, field reflect.StructField, value string) error {
-	if rv.Kind() == reflect.Slice {
-		return setSlice(rv, field, value)
+	enum, err := fieldEnum(field)
+	if err != nil {
+		return err
 	}
 
-	// ensure pointer values are non-nil
-	if rv.Kind() == reflect.Ptr {
-		if rv.IsNil() {
-			rv.Set(reflect.New(rv.Type().Elem()))
-		}
+	if rv.Kind() == reflect.Slice && !hasTypeParser(rv.Type()) {
+		return setSlice(rv, value, enum)
 	}
 
-	if tm := asTextUnmarshaller(rv); tm != nil {
-		return tm.UnmarshalText([]byte(value))
+	val, err := parseItem(rv.Type(), value, enum)
+	if err != nil {
+		return err
 	}
+	rv.Set(val)
+	return nil
+}
 
-	fieldType := field.Type
-	if fieldType.Kind() == reflect.Ptr {
-		fieldType = fieldType.Elem()
-		rv = rv.Elem()
-	}
+// populate a slice with multiple values parsed from string.
+func setSlice(rv reflect.Value, value string, enum *enumTable) error {
+	var (
+		parts    = splitList(value)
+		itemType = rv.Type().Elem()
+		values   = reflect.MakeSlice(rv.Type(), len(parts), len(parts))
+	)
 
-	if parseFn, ok := getParseFunc(fieldType); ok {
-		val, err := parseFn(value)
+	for i, s := range parts {
+		val, err := parseItem(itemType, s, enum)
 		if err != nil {
 			return err
 		}
-		rv.Set(reflect.ValueOf(val).Convert(fieldType))
-		return nil
+		values.Index(i).Set(val)
 	}
 
-	return ErrUnsupported(fieldType.String())
+	rv.Set(values)
+	return nil
 }
 
-// populate a slice with multiple values parsed from string.
-func setSlice(rv reflect.Value, field reflect.StructField, value string) error {
-	parts := strings.Split(value, ",")
+// splitList splits a variable's value into the items of a slice.
+func splitList(s string) []string {
+	return strings.Split(s, ",")
+}
 
-	fieldType := field.Type.Elem()
-	if fieldType.Kind() == reflect.Ptr {
-		fieldType = fieldType.Elem()
+// parseItem parses a single value of type typ from a string. If enum is
+// non-nil, the string is treated as the name of a value in enum.
+func parseItem(typ reflect.Type, s string, enum *enumTable) (reflect.Value, error) {
+	if enum == nil {
+		return parseValue(typ, s)
 	}
 
-	if _, ok := reflect.New(fieldType).Interface().(encoding.TextUnmarshaler); ok {
-		return unmarshalSlice(rv, parts)
+	if typ.Kind() == reflect.Ptr {
+		val, err := parseItem(typ.Elem(), s, enum)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(typ.Elem())
+		p.Elem().Set(val)
+		return p, nil
 	}
 
-	parseFn, ok := getParseFunc(fieldType)
-	if !ok {
-		return ErrUnsupported(fieldType.String())
+	// a parser registered for *T also parses T
+	if fun, ok := lookupParser(reflect.PtrTo(typ)); ok {
+		v, err := fun(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.ValueOf(v)
+		if !p.IsValid() || p.IsNil() {
+			return reflect.Zero(typ), nil
+		}
+		return p.Elem(), nil
 	}
+	return enum.parse(s)
+}
 
-	values := reflect.MakeSlice(field.Type, 0, len(parts))
-	for _, s := range parts {
-		v, err := parseFn(s)
+// parseValue parses a string into a new value of type typ. Parsers are
+// tried in the following order: typeParsers, pointer dereferencing,
+// encoding.TextUnmarshaler, kindParsers.
+func parseValue(typ reflect.Type, s string) (reflect.Value, error) {
+	if fun, ok := lookupParser(typ); ok {
+		v, err := fun(s)
 		if err != nil {
-			return err
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(v).Convert(typ), nil
+	}
+
+	if typ.Kind() == reflect.Ptr {
+		val, err := parseValue(typ.Elem(), s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(typ.Elem())
+		p.Elem().Set(val)
+		return p, nil
+	}
+
+	// a parser registered for *T also parses T
+	if fun, ok := lookupParser(reflect.PtrTo(typ)); ok {
+		v, err := fun(s)
+		if err != nil {
+			return reflect.Value{}, err
 		}
-		val := reflect.ValueOf(v).Convert(fieldType)
-		if field.Type.Elem().Kind() == reflect.Ptr {
-			val = reflect.New(fieldType)
-			val.Elem().Set(reflect.ValueOf(v).Convert(fieldType))
+		p := reflect.ValueOf(v)
+		if !p.IsValid() || p.IsNil() {
+			return reflect.Zero(typ), nil
 		}
-		values = reflect.Append(values, val)
+		return p.Elem(), nil
 	}
 
-	rv.Set(values)
-	return nil
-}
+	p := reflect.New(typ)
+	if tm, ok := p.Interface().(encoding.TextUnmarshaler); ok {
+		if err := tm.UnmarshalText([]byte(s)); err != nil {
+			return reflect.Value{}, err
+		}
+		return p.Elem(), nil
+	}
 
-func asTextUnmarshaller(rv reflect.Value) encoding.TextUnmarshaler {
-	if tm, ok := rv.Interface().(encoding.TextUnmarshaler); ok {
-		return tm
+	if fun, ok := kindParsers[typ.Kind()]; ok {
+		v, err := fun(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(v).Convert(typ), nil
 	}
-	return nil
+
+	return reflect.Value{}, ErrUnsupported(typ.String())
 }
 
-func unmarshalSlice(rv reflect.Value, parts []string) error {
-	itemType := rv.Type().Elem()
-	values := reflect.MakeSlice(reflect.SliceOf(itemType), len(parts), len(parts))
-	for i, s := range parts {
-		sv := values.Index(i)
-		kind := sv.Kind()
-		if kind == reflect.Ptr {
-			sv = reflect.New(itemType.Elem())
+// parse a complex number in the form "(1.5+2i)". The parentheses are
+// optional, as is either the real or the imaginary part.
+func parseComplex(s string, bitSize int) (complex128, error) {
+	var (
+		orig     = s
+		size     = bitSize / 2
+		re, im   float64
+		err      error
+		errValue = fmt.Errorf("invalid complex number: %s", orig)
+	)
+
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s = s[1 : len(s)-1]
+	}
+	if s == "" {
+		return 0, errValue
+	}
+
+	if !strings.HasSuffix(s, "i") {
+		if re, err = strconv.ParseFloat(s, size); err != nil {
+			return 0, errValue
 		}
-		tm := sv.Interface().(encoding.TextUnmarshaler)
-		if err := tm.UnmarshalText([]byte(s)); err != nil {
-			return err
+		return complex(re, 0), nil
+	}
+	s = s[:len(s)-1]
+
+	// find the sign separating the real and imaginary parts, ignoring
+	// a leading sign and those belonging to exponents
+	i := len(s) - 1
+	for ; i > 0; i-- {
+		if (s[i] == '+' || s[i] == '-') && s[i-1] != 'e' && s[i-1] != 'E' {
+			break
+		}
+	}
+
+	if i > 0 {
+		if re, err = strconv.ParseFloat(s[:i], size); err != nil {
+			return 0, errValue
 		}
-		if kind == reflect.Ptr {
-			values.Index(i).Set(sv)
+		s = s[i:]
+	}
+	switch s {
+	case "", "+":
+		im = 1
+	case "-":
+		im = -1
+	default:
+		if im, err = strconv.ParseFloat(s, size); err != nil {
+			return 0, errValue
 		}
 	}
+	return complex(re, im), nil
+}
 
-	rv.Set(values)
-	return nil
+// parse an arbitrary-precision integer. Base prefixes (0x, 0o, 0b)
+// are supported.
+func parseBigInt(s string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer: %s", s)
+	}
+	return n, nil
+}
+
+// parse an arbitrary-precision float. The precision of the returned
+// value is sufficient to represent all the digits in s.
+func parseBigFloat(s string) (*big.Float, error) {
+	prec := uint(len(s)) * 4 // > log2(10) bits per digit
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("invalid float %q: %w", s, err)
+	}
+	return f, nil
+}
+
+// parse a rational number, either as a fraction ("3/4") or a
+// decimal ("0.75").
+func parseBigRat(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid rational number: %s", s)
+	}
+	return r, nil
 }
 
 // VarName generates an environment variable name from a field name.
@@ -3,11 +3,16 @@
 package env
 
 import (
+	"bytes"
 	"fmt"
+	"math"
+	"math/big"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
+	"text/template"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -347,6 +352,72 @@ func TestBind_nested(t *testing.T) {
 	assert.Equal(t, x, target, "unexpected result")
 }
 
+func TestBind_nilNested(t *testing.T) {
+	type inner struct {
+		Host string
+		Port int `env:",required"`
+	}
+	type config struct {
+		DB *inner
+	}
+
+	var c config
+	require.NoError(t, Bind(&c, MapEnv{"HOST": "x", "PORT": "5432"}), "bind failed")
+	assert.Equal(t, &inner{Host: "x", Port: 5432}, c.DB, "unexpected DB")
+
+	// left nil if no variables are set, so required fields are optional
+	c = config{}
+	var r Report
+	require.NoError(t, BindWith(&c, MapEnv{"OTHER": "x"}, WithReport(&r)), "bind failed")
+	assert.Nil(t, c.DB, "DB set")
+	assert.Equal(t, 0, len(r), "unexpected report")
+
+	c = config{}
+	err := Bind(&c, MapEnv{"HOST": "x"})
+	assert.EqualError(t, err, "missing required variable: PORT", "unexpected error")
+}
+
+func TestBind_required(t *testing.T) {
+	var v exampleConfig
+	err := Bind(&v, MapEnv{"HOST": "localhost", "PASSWORD": ""})
+	assert.EqualError(t, err, "missing required variable: PASSWORD", "unexpected error")
+	assert.Equal(t, "localhost", v.Host, "struct not populated")
+
+	err = Bind(&v, MapEnv{})
+	assert.Equal(t, &MissingVarsError{Vars: []string{"HOST", "PASSWORD"}}, err, "unexpected error")
+	assert.EqualError(t, err, "missing required variables: HOST, PASSWORD", "unexpected error")
+
+	assert.NoError(t, Bind(&v, MapEnv{"HOST": "localhost", "PASSWORD": "x"}), "bind failed")
+
+	// fields of items beyond the end of a slice aren't required
+	w := struct {
+		Items []struct {
+			Name string `env:",required"`
+		}
+	}{}
+	assert.NoError(t, Bind(&w, MapEnv{"ITEMS_0_NAME": "a"}), "bind failed")
+	assert.Equal(t, 1, len(w.Items), "unexpected item count")
+	assert.EqualError(t, Bind(&w, MapEnv{"ITEMS_COUNT": "1"}),
+		"missing required variable: ITEMS_0_NAME", "unexpected error")
+}
+
+// Recursive types are not allocated again while being populated.
+func TestBind_recursive(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+
+	var n node
+	require.NoError(t, Bind(&n, MapEnv{"NAME": "a"}), "bind failed")
+	assert.Equal(t, node{Name: "a"}, n, "unexpected result")
+
+	// existing values are still populated
+	n = node{Next: &node{}}
+	require.NoError(t, Bind(&n, MapEnv{"NAME": "a"}), "bind failed")
+	assert.Equal(t, node{Name: "a", Next: &node{Name: "a"}}, n, "unexpected result")
+}
+
 func TestBind_embedded(t *testing.T) {
 	env := MapEnv{
 		"NESTED_STRING": "Nested",
@@ -431,6 +502,131 @@ func TestBind_invalidTypes(t *testing.T) {
 	}
 }
 
+type Upstream struct {
+	Host    string
+	Port    int
+	Tags    []string
+	Backoff *Nested
+}
+
+func TestBind_structSlice(t *testing.T) {
+	type target struct {
+		Upstreams  []Upstream
+		UpstreamsP []*Upstream `env:"UPSTREAMS"`
+		Mirrors    []Upstream
+		Ignored    []Upstream `env:"-"`
+	}
+
+	e := MapEnv{
+		"UPSTREAMS_0_HOST":          "one.example.com",
+		"UPSTREAMS_0_PORT":          "80",
+		"UPSTREAMS_0_NESTED_STRING": "nested",
+		"UPSTREAMS_1_HOST":          "two.example.com",
+		"UPSTREAMS_1_TAGS":          "a,b",
+		// gap
+		"UPSTREAMS_3_HOST": "four.example.com",
+
+		"MIRRORS_COUNT":  "3",
+		"MIRRORS_1_PORT": "8080",
+
+		"IGNORED_0_HOST": "ignored.example.com",
+	}
+
+	x := []Upstream{
+		{Host: "one.example.com", Port: 80, Backoff: &Nested{NestedString: "nested"}},
+		{Host: "two.example.com", Tags: []string{"a", "b"}, Backoff: &Nested{}},
+	}
+
+	v := target{
+		// existing items are defaults
+		Upstreams: []Upstream{{Port: 443, Backoff: &Nested{}}, {Port: 443, Backoff: &Nested{}}},
+		Mirrors:   []Upstream{{Host: "default.example.com"}},
+	}
+	require.NoError(t, Bind(&v, e), "bind failed")
+
+	x[1].Port = 443
+	assert.Equal(t, x, v.Upstreams, "unexpected Upstreams")
+
+	require.Len(t, v.UpstreamsP, 2, "unexpected UpstreamsP")
+	assert.Equal(t, "one.example.com", v.UpstreamsP[0].Host, "unexpected UpstreamsP[0]")
+	assert.Equal(t, "two.example.com", v.UpstreamsP[1].Host, "unexpected UpstreamsP[1]")
+
+	assert.Equal(t, []Upstream{
+		{Host: "default.example.com"},
+		{Port: 8080},
+		{},
+	}, v.Mirrors, "unexpected Mirrors")
+	assert.Nil(t, v.Ignored, "unexpected Ignored")
+
+	// unset slices are unchanged
+	v = target{Mirrors: []Upstream{{Host: "default.example.com"}}}
+	require.NoError(t, Bind(&v, MapEnv{}), "bind failed")
+	assert.Equal(t, []Upstream{{Host: "default.example.com"}}, v.Mirrors, "unexpected Mirrors")
+
+	// errors
+	assert.Error(t, Bind(&v, MapEnv{"MIRRORS_COUNT": "two"}), "accepted invalid count")
+	assert.Error(t, Bind(&v, MapEnv{"MIRRORS_COUNT": "-1"}), "accepted negative count")
+	assert.Error(t, Bind(&v, MapEnv{"MIRRORS_0_PORT": "eighty"}), "accepted invalid item")
+}
+
+// funcEnv is an Env that can't list its variables.
+type funcEnv func(key string) (string, bool)
+
+func (fn funcEnv) Lookup(key string) (string, bool) { return fn(key) }
+
+type DBConfig struct {
+	Host     string
+	Port     int
+	Replicas []Upstream
+}
+
+func TestBind_structMap(t *testing.T) {
+	type target struct {
+		DB      map[string]DBConfig
+		DBP     map[string]*DBConfig `env:"DB"`
+		Caches  map[string]DBConfig
+		Ignored map[string]DBConfig `env:"-"`
+	}
+
+	e := MapEnv{
+		"DB_PRIMARY_HOST":              "db1.example.com",
+		"DB_PRIMARY_PORT":              "5432",
+		"DB_READ_ONLY_HOST":            "db2.example.com",
+		"DB_ANALYTICS_REPLICAS_0_HOST": "db3.example.com",
+		"DB_UNKNOWN_FIELD":             "ignored",
+		"DB_HOST":                      "ignored",
+		"IGNORED_X_HOST":               "ignored",
+	}
+
+	v := target{
+		DB: map[string]DBConfig{
+			"PRIMARY": {Port: 5433},
+			"DEFAULT": {Host: "localhost"},
+		},
+	}
+	require.NoError(t, Bind(&v, e), "bind failed")
+
+	assert.Equal(t, map[string]DBConfig{
+		"PRIMARY":   {Host: "db1.example.com", Port: 5432},
+		"READ_ONLY": {Host: "db2.example.com"},
+		"ANALYTICS": {Replicas: []Upstream{{Host: "db3.example.com"}}},
+		"DEFAULT":   {Host: "localhost"},
+	}, v.DB, "unexpected DB")
+
+	require.Len(t, v.DBP, 3, "unexpected DBP")
+	assert.Equal(t, &DBConfig{Host: "db2.example.com"}, v.DBP["READ_ONLY"], "unexpected DBP")
+	assert.Nil(t, v.Caches, "unexpected Caches")
+	assert.Nil(t, v.Ignored, "unexpected Ignored")
+
+	// Env can't list variables
+	v = target{}
+	require.NoError(t, Bind(&v, funcEnv(e.Lookup)), "bind failed")
+	assert.Nil(t, v.DB, "unexpected DB")
+
+	// errors
+	assert.Error(t, Bind(&v, MapEnv{"DB_X_PORT": "eighty"}), "accepted invalid item")
+}
+
 func TestBind_invalidValues(t *testing.T) {
 	tests := []struct {
 		key, val string
@@ -496,6 +692,143 @@ func TestBind_invalidValues(t *testing.T) {
 	assert.Error(t, err, "dump accepted bogus target")
 }
 
+func TestBind_regexpTemplate(t *testing.T) {
+	v := struct {
+		Pattern  *regexp.Regexp
+		Patterns []*regexp.Regexp
+		Body     *template.Template
+	}{}
+	e := MapEnv{
+		"PATTERN":  "^a+$",
+		"PATTERNS": `^foo$,^\d+$`,
+		"BODY":     "Hello, {{.}}!",
+	}
+
+	require.NoError(t, Bind(&v, e), "bind failed")
+	assert.Equal(t, "^a+$", v.Pattern.String(), "unexpected Pattern")
+	assert.True(t, v.Pattern.MatchString("aaa"), "Pattern didn't match")
+	require.Len(t, v.Patterns, 2, "unexpected Patterns")
+	assert.True(t, v.Patterns[0].MatchString("foo"), "Patterns[0] didn't match")
+	assert.True(t, v.Patterns[1].MatchString("123"), "Patterns[1] didn't match")
+
+	var buf bytes.Buffer
+	require.NoError(t, v.Body.Execute(&buf, "Bob"), "execute template failed")
+	assert.Equal(t, "Hello, Bob!", buf.String(), "unexpected template output")
+
+	assert.Error(t, Bind(&v, MapEnv{"PATTERN": "(a"}), "accepted invalid regexp")
+	assert.Error(t, Bind(&v, MapEnv{"PATTERNS": "a,(a"}), "accepted invalid regexp")
+	assert.Error(t, Bind(&v, MapEnv{"BODY": "{{.Oops"}), "accepted invalid template")
+}
+
+// url.URL and time.Time are handled the same way, whether they're values,
+// pointers or slice items.
+func TestBind_valueTypes(t *testing.T) {
+	var (
+		u1, _ = url.Parse("https://www.example.com/path?q=1")
+		u2, _ = url.Parse("ftp://example.org")
+		t1    = time.Date(2020, 7, 31, 12, 0, 0, 0, time.UTC)
+	)
+
+	v := struct {
+		URL   url.URL
+		URLP  *url.URL
+		URLS  []url.URL
+		URLSP []*url.URL
+		Time  time.Time
+		Times []time.Time
+	}{}
+	e := MapEnv{
+		"URL":   u1.String(),
+		"URLP":  u1.String(),
+		"URLS":  u1.String() + "," + u2.String(),
+		"URLSP": u1.String() + "," + u2.String(),
+		"TIME":  t1.Format(time.RFC3339),
+		"TIMES": t1.Format(time.RFC3339),
+	}
+
+	require.NoError(t, Bind(&v, e), "bind failed")
+	assert.Equal(t, *u1, v.URL, "unexpected URL")
+	assert.Equal(t, u1, v.URLP, "unexpected URLP")
+	assert.Equal(t, []url.URL{*u1, *u2}, v.URLS, "unexpected URLS")
+	assert.Equal(t, []*url.URL{u1, u2}, v.URLSP, "unexpected URLSP")
+	assert.Equal(t, t1, v.Time, "unexpected Time")
+	assert.Equal(t, []time.Time{t1}, v.Times, "unexpected Times")
+}
+
+func TestBind_bigComplex(t *testing.T) {
+	v := struct {
+		Int     *big.Int
+		Float   *big.Float
+		Rat     *big.Rat
+		Rats    []*big.Rat
+		Complex complex128
+		Small   complex64
+	}{}
+	e := MapEnv{
+		"INT":     "-98765432109876543210",
+		"FLOAT":   "1.00000000000000000000000001",
+		"RAT":     "0.0125",
+		"RATS":    "1/3,2/3",
+		"COMPLEX": "(1e3-2.5e-1i)",
+		"SMALL":   "-1i",
+	}
+
+	require.NoError(t, Bind(&v, e), "bind failed")
+	assert.Equal(t, "-98765432109876543210", v.Int.String(), "unexpected Int")
+	assert.Equal(t, "1.00000000000000000000000001", v.Float.Text('g', -1), "unexpected Float")
+	assert.Equal(t, big.NewRat(1, 80), v.Rat, "unexpected Rat")
+	assert.Equal(t, []*big.Rat{big.NewRat(1, 3), big.NewRat(2, 3)}, v.Rats, "unexpected Rats")
+	assert.Equal(t, complex(1000, -0.25), v.Complex, "unexpected Complex")
+	assert.Equal(t, complex64(-1i), v.Small, "unexpected Small")
+
+	for k, s := range map[string]string{
+		"INT":     "1.5",
+		"FLOAT":   "one",
+		"RAT":     "1/0",
+		"COMPLEX": "1+2j",
+		"SMALL":   "()",
+	} {
+		assert.Errorf(t, Bind(&v, MapEnv{k: s}), "%s: invalid value accepted", k)
+	}
+}
+
+func TestParseComplex(t *testing.T) {
+	tests := []struct {
+		in  string
+		x   complex128
+		err bool
+	}{
+		{"0", 0, false},
+		{"1", 1, false},
+		{"(1)", 1, false},
+		{"i", 1i, false},
+		{"-i", -1i, false},
+		{"+2i", 2i, false},
+		{"1+i", 1 + 1i, false},
+		{"(1.5-2i)", complex(1.5, -2), false},
+		{"-1e+3+1E-3i", complex(-1000, 0.001), false},
+		{"(+Inf-Infi)", complex(math.Inf(1), math.Inf(-1)), false},
+		{"", 0, true},
+		{"()", 0, true},
+		{"1+2j", 0, true},
+		{"x+2i", 0, true},
+		{"1+xi", 0, true},
+	}
+
+	for _, td := range tests {
+		td := td
+		t.Run(td.in, func(t *testing.T) {
+			c, err := parseComplex(td.in, 128)
+			if td.err {
+				assert.Error(t, err, "invalid value accepted")
+				return
+			}
+			require.NoError(t, err, "parse failed")
+			assert.Equal(t, td.x, c, "unexpected result")
+		})
+	}
+}
+
 // Populate a struct from environment variables.
 func ExampleBind() {
 	// Simple configuration struct
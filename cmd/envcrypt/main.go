@@ -0,0 +1,128 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+/*
+
+Command envcrypt generates keys and encrypts values for env.DecryptingEnv.
+
+Generate a key:
+
+	envcrypt -genkey > .env.key
+
+Encrypt the value of a variable (read from stdin if no value is given):
+
+	envcrypt -key .env.key -name DB_PASS 'hunter2'
+	// Output:
+	// enc:v1:...
+
+Add the output to a .env file as DB_PASS=enc:v1:... The value can only
+be decrypted as the value of the named variable.
+
+Decrypt a value:
+
+	envcrypt -key .env.key -name DB_PASS -d 'enc:v1:...'
+
+Instead of -key, pass -key-var to read the key from an environment
+variable.
+
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"go.deanishe.net/env"
+)
+
+var (
+	genKey  = flag.Bool("genkey", false, "generate a new key")
+	decrypt = flag.Bool("d", false, "decrypt instead of encrypt")
+	keyFile = flag.String("key", "", "read key from `file`")
+	keyVar  = flag.String("key-var", "", "read key from environment `variable`")
+	name    = flag.String("name", "", "`name` of the variable the value belongs to")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("envcrypt: ")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: envcrypt -genkey\n       envcrypt (-key <file>|-key-var <var>) -name <name> [-d] [<value>]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *genKey {
+		key, err := env.GenerateKey()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(env.EncodeKey(key))
+		return
+	}
+
+	if *name == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	key, err := readKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	value, err := readValue()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *decrypt {
+		s, err := env.Decrypt(key, *name, value)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(s)
+		return
+	}
+
+	s, err := env.Encrypt(key, *name, value)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(s)
+}
+
+// readKey loads the key specified by -key or -key-var.
+func readKey() ([]byte, error) {
+	switch {
+	case *keyFile != "":
+		return env.KeyFromFile(*keyFile)
+	case *keyVar != "":
+		return env.KeyFromEnv(env.System, *keyVar)
+	}
+	flag.Usage()
+	os.Exit(2)
+	return nil, nil
+}
+
+// readValue returns the command-line argument or the contents of stdin
+// minus a trailing newline.
+func readValue() (string, error) {
+	switch flag.NArg() {
+	case 0:
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(data), "\n"), nil
+	case 1:
+		return flag.Arg(0), nil
+	}
+	flag.Usage()
+	os.Exit(2)
+	return "", nil
+}
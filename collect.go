@@ -0,0 +1,131 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ReadError is returned by Reader.Err and contains all the errors
+// encountered by a collecting Reader.
+type ReadError struct {
+	// Errs is a *MissingVarsError listing any required variables that
+	// were unset or empty, followed by the errors for invalid values
+	// in the order they were read.
+	Errs []error
+}
+
+// Error implements error.
+func (err *ReadError) Error() string {
+	msgs := make([]string, len(err.Errs))
+	for i, e := range err.Errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is returns true if any of the errors matches target, so errors.Is
+// looks inside a ReadError.
+func (err *ReadError) Is(target error) bool {
+	for _, e := range err.Errs {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error that matches target, so errors.As looks
+// inside a ReadError.
+func (err *ReadError) As(target interface{}) bool {
+	for _, e := range err.Errs {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Collecting returns a Reader for the default Env that collects errors. See Reader.Collecting.
+func Collecting() Reader {
+	return Default().Collecting()
+}
+
+// Collecting returns a copy of the Reader that records errors, which
+// are then returned by Err. The Get* methods still return fallback values,
+// but also record invalid values and required variables that are unset
+// or empty. A variable is required if no fallback is passed. As Get returns
+// the value of a variable that is set but empty, Get only records unset
+// variables as missing.
+//
+// Readers created from the returned Reader with Sub share its errors.
+func (r Reader) Collecting() Reader {
+	return Reader{env: r.env, prefix: r.prefix, errs: &collector{seen: map[string]bool{}}}
+}
+
+// Err returns a *ReadError containing every error recorded by a collecting
+// Reader, or nil if there were none or the Reader isn't collecting errors.
+//
+// Call it after reading all variables, like bufio.Scanner.Err:
+//
+//	r := env.Collecting()
+//	host := r.Get("HOST")
+//	port := r.GetInt("PORT", 80)
+//	if err := r.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+func (r Reader) Err() error {
+	return r.errs.err()
+}
+
+// collector records the errors encountered by a collecting Reader.
+// Readers created with Sub share their parent's collector.
+type collector struct {
+	mu      sync.Mutex
+	seen    map[string]bool // keys already reported
+	missing []string
+	errs    []error
+}
+
+// add records a missing required variable or an invalid value for the
+// full (prefixed) variable name key. Only the first error for each key is
+// recorded. It is a no-op on a nil collector.
+func (c *collector) add(key string, ok bool, err error, required bool) {
+	if c == nil || (err == nil && (ok || !required)) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	if err != nil {
+		c.errs = append(c.errs, err)
+	} else {
+		c.missing = append(c.missing, key)
+	}
+}
+
+// err returns the recorded errors as a *ReadError or nil.
+func (c *collector) err() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	if len(c.missing) > 0 {
+		errs = append(errs, &MissingVarsError{Vars: append([]string{}, c.missing...)})
+	}
+	errs = append(errs, c.errs...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ReadError{Errs: errs}
+}
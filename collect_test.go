@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollecting(t *testing.T) {
+	env := MapEnv{
+		"HOST":       "localhost",
+		"PORT":       "80a",
+		"TIMEOUT":    "5",
+		"DEBUG":      "yes",
+		"LEVEL":      "loud",
+		"EMPTY":      "",
+		"REDIS_PORT": "x",
+	}
+
+	// not collecting
+	r := New(env)
+	assert.Equal(t, 0, r.GetInt("PORT"), "unexpected PORT")
+	assert.Nil(t, r.Err(), "unexpected error")
+
+	r = r.Collecting()
+	assert.Nil(t, r.Err(), "unexpected error")
+
+	assert.Equal(t, "localhost", r.Get("HOST"), "unexpected HOST")
+	assert.Equal(t, 8080, r.GetInt("PORT", 8080), "unexpected PORT")
+	assert.Equal(t, 8080, r.GetInt("PORT", 8080), "unexpected PORT")
+	assert.Equal(t, time.Minute, r.GetDuration("TIMEOUT", time.Minute), "unexpected TIMEOUT")
+	assert.False(t, r.GetBool("DEBUG", false), "unexpected DEBUG")
+	assert.Equal(t, levelInfo, r.GetEnum("LEVEL", levelInfo), "unexpected LEVEL")
+	assert.Equal(t, 1.5, r.GetFloat("RATIO", 1.5), "unexpected RATIO")
+	assert.Equal(t, "", r.Get("USER"), "unexpected USER")
+	assert.Equal(t, "", r.Get("EMPTY"), "unexpected EMPTY") // set, so not missing
+	assert.Equal(t, 0, r.GetInt("EMPTY"), "unexpected EMPTY")
+	assert.Equal(t, uint(0), r.GetUint("WORKERS"), "unexpected WORKERS")
+	assert.Equal(t, 6379, r.Sub("REDIS_").GetInt("PORT", 6379), "unexpected REDIS_PORT")
+
+	err := r.Err()
+	require.Error(t, err, "no error collected")
+	assert.EqualError(t, err, "missing required variables: USER, EMPTY, WORKERS; "+
+		"invalid value for PORT: invalid int: 80a; "+
+		`invalid value for TIMEOUT: time: missing unit in duration "5"; `+
+		`invalid value for DEBUG: strconv.ParseBool: parsing "yes": invalid syntax; `+
+		`invalid value for LEVEL: invalid testLevel "loud" (allowed values: WARN2, debug, info, warn); `+
+		"invalid value for REDIS_PORT: invalid int: x", "unexpected error")
+
+	re, ok := err.(*ReadError)
+	require.True(t, ok, "not a *ReadError: %v", err)
+	assert.Equal(t, 6, len(re.Errs), "unexpected errors")
+
+	var mve *MissingVarsError
+	require.True(t, errors.As(err, &mve), "no MissingVarsError")
+	assert.Equal(t, []string{"USER", "EMPTY", "WORKERS"}, mve.Vars, "unexpected missing vars")
+	assert.True(t, errors.Is(err, strconv.ErrSyntax), "unexpected error")
+
+	// new collection
+	assert.Nil(t, r.Collecting().Err(), "errors shared")
+
+	// prefix of Sub Reader is kept
+	r = New(env).Sub("REDIS_").Collecting()
+	assert.Equal(t, 0, r.GetInt("PORT"), "unexpected REDIS_PORT")
+	assert.Equal(t, "", r.Get("HOST"), "unexpected REDIS_HOST")
+	assert.EqualError(t, r.Err(), "missing required variable: REDIS_HOST; "+
+		"invalid value for REDIS_PORT: invalid int: x", "unexpected error")
+}
+
+// Report all invalid and missing variables at once.
+func ExampleCollecting() {
+	_ = os.Setenv("HOST", "localhost")
+	_ = os.Setenv("PORT", "80a")
+
+	r := Collecting()
+	host := r.Get("HOST")
+	port := r.GetInt("PORT", 80)
+	user := r.Get("USER")
+
+	fmt.Println(host, port, user == "")
+	fmt.Println(r.Err())
+
+	// Output:
+	// localhost 80 true
+	// missing required variable: USER; invalid value for PORT: invalid int: 80a
+
+	os.Clearenv()
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// EncryptedPrefix marks an encrypted value. It is followed by the
+// base64-encoded nonce and AES-GCM ciphertext, which is bound to the
+// name of the variable.
+const EncryptedPrefix = "enc:v1:"
+
+// KeySize is the length of keys in bytes. Keys are AES-256 keys.
+const KeySize = 32
+
+// ErrDecrypt is returned if an encrypted value can't be decrypted, because
+// it is corrupt or encrypted with a different key.
+var ErrDecrypt = errors.New("cannot decrypt value")
+
+// GenerateKey returns a new random key for Encrypt and DecryptingEnv.
+// Use EncodeKey to convert it to text.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EncodeKey returns key as base64, the format read by ParseKey.
+func EncodeKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// ParseKey decodes a base64-encoded key. Surrounding whitespace is ignored.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key: %d bytes, not %d", len(key), KeySize)
+	}
+	return key, nil
+}
+
+// KeyFromFile reads a base64-encoded key from a file.
+func KeyFromFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKey(string(data))
+}
+
+// KeyFromEnv reads a base64-encoded key from variable name in env.
+func KeyFromEnv(env Env, name string) ([]byte, error) {
+	s, ok := env.Lookup(name)
+	if !ok || s == "" {
+		return nil, fmt.Errorf("key variable %s is not set", name)
+	}
+	return ParseKey(s)
+}
+
+// Encrypt encrypts plaintext, the value of variable name, with key using
+// AES-GCM. The returned value starts with EncryptedPrefix.
+//
+// The name is authenticated along with the value, so the value can only be
+// decrypted as the value of the same variable. Encrypted values therefore
+// can't be swapped between variables without Decrypt failing.
+func Encrypt(key []byte, name, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	data := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(name))
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Decrypt decrypts value, the value of variable name, created by Encrypt.
+// It returns ErrDecrypt if value was encrypted for a different variable.
+// Values that don't start with EncryptedPrefix are returned unchanged.
+func Decrypt(key []byte, name, value string) (string, error) {
+	if !strings.HasPrefix(value, EncryptedPrefix) {
+		return value, nil
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value[len(EncryptedPrefix):])
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", ErrDecrypt
+	}
+	n := gcm.NonceSize()
+	plaintext, err := gcm.Open(nil, data[:n], data[n:], []byte(name))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key: %d bytes, not %d", len(key), KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// DecryptingEnv returns an Env that reads values from env and decrypts
+// those that start with EncryptedPrefix with key. Other values are
+// returned unchanged. Values are decrypted as values of the variable
+// they are looked up as, so each must have been encrypted with that name.
+//
+// Bind returns an error if a value can't be decrypted (see FallibleEnv).
+func DecryptingEnv(env Env, key []byte) Env {
+	return decryptingEnv{env, key}
+}
+
+// decryptingEnv implements DecryptingEnv.
+type decryptingEnv struct {
+	env Env
+	key []byte
+}
+
+// Lookup implements Env.
+func (de decryptingEnv) Lookup(key string) (string, bool) {
+	s, ok, err := de.LookupErr(key)
+	if err != nil {
+		return "", false
+	}
+	return s, ok
+}
+
+// LookupErr implements FallibleEnv.
+func (de decryptingEnv) LookupErr(key string) (string, bool, error) {
+	s, _, ok, err := lookupSource(de.env, key)
+	if err != nil || !ok {
+		return "", ok, err
+	}
+	if s, err = Decrypt(de.key, key, s); err != nil {
+		return "", false, err
+	}
+	return s, true, nil
+}
+
+// Keys implements Lister.
+func (de decryptingEnv) Keys() []string { return Keys(de.env, "") }
+
+// Name implements Namer.
+func (de decryptingEnv) Name() string { return SourceName(de.env) }
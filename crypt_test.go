@@ -0,0 +1,177 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(t *testing.T) []byte {
+	key, err := GenerateKey()
+	require.NoError(t, err, "generate key")
+	require.Equal(t, KeySize, len(key), "unexpected key size")
+	return key
+}
+
+func TestEncrypt(t *testing.T) {
+	key := testKey(t)
+
+	for _, s := range []string{"", "hunter2", "line 1\nline 2", "✓"} {
+		c, err := Encrypt(key, "VAR", s)
+		require.NoError(t, err, "encrypt failed")
+		assert.True(t, strings.HasPrefix(c, EncryptedPrefix), "missing prefix")
+		if s != "" {
+			assert.NotContains(t, c[len(EncryptedPrefix):], s, "plaintext in ciphertext")
+		}
+
+		c2, err := Encrypt(key, "VAR", s)
+		require.NoError(t, err, "encrypt failed")
+		assert.NotEqual(t, c, c2, "nonce reused")
+
+		p, err := Decrypt(key, "VAR", c)
+		require.NoError(t, err, "decrypt failed")
+		assert.Equal(t, s, p, "unexpected plaintext")
+	}
+
+	// plain values are unchanged
+	p, err := Decrypt(key, "VAR", "hunter2")
+	require.NoError(t, err, "decrypt failed")
+	assert.Equal(t, "hunter2", p, "unexpected plaintext")
+
+	// wrong key
+	c, err := Encrypt(key, "VAR", "hunter2")
+	require.NoError(t, err, "encrypt failed")
+	_, err = Decrypt(testKey(t), "VAR", c)
+	assert.Equal(t, ErrDecrypt, err, "unexpected error")
+
+	// corrupt values
+	for _, s := range []string{EncryptedPrefix, EncryptedPrefix + "!!", EncryptedPrefix + "YWJj", c[:len(c)-4] + "AAAA"} {
+		_, err = Decrypt(key, "VAR", s)
+		assert.Equal(t, ErrDecrypt, err, "unexpected error for %q", s)
+	}
+
+	// value encrypted for a different variable
+	_, err = Decrypt(key, "OTHER", c)
+	assert.Equal(t, ErrDecrypt, err, "unexpected error")
+
+	_, err = Encrypt([]byte("short"), "VAR", "x")
+	assert.EqualError(t, err, "invalid key: 5 bytes, not 32", "unexpected error")
+}
+
+func TestParseKey(t *testing.T) {
+	key := testKey(t)
+
+	k, err := ParseKey(" " + EncodeKey(key) + "\n")
+	require.NoError(t, err, "parse failed")
+	assert.Equal(t, key, k, "unexpected key")
+
+	_, err = ParseKey("not base64!")
+	assert.Error(t, err, "accepted invalid key")
+	_, err = ParseKey("YWJj")
+	assert.EqualError(t, err, "invalid key: 3 bytes, not 32", "unexpected error")
+
+	dir, err := ioutil.TempDir("", "env-")
+	require.NoError(t, err, "create tempdir")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ".env.key")
+	require.NoError(t, ioutil.WriteFile(path, []byte(EncodeKey(key)+"\n"), 0600), "write key")
+
+	k, err = KeyFromFile(path)
+	require.NoError(t, err, "read key file")
+	assert.Equal(t, key, k, "unexpected key")
+	_, err = KeyFromFile(filepath.Join(dir, "missing"))
+	assert.True(t, os.IsNotExist(err), "unexpected error")
+
+	k, err = KeyFromEnv(MapEnv{"KEY": EncodeKey(key)}, "KEY")
+	require.NoError(t, err, "read key variable")
+	assert.Equal(t, key, k, "unexpected key")
+	_, err = KeyFromEnv(MapEnv{}, "KEY")
+	assert.EqualError(t, err, "key variable KEY is not set", "unexpected error")
+}
+
+func TestDecryptingEnv(t *testing.T) {
+	key := testKey(t)
+	pass, err := Encrypt(key, "DB_PASS", "hunter2")
+	require.NoError(t, err, "encrypt failed")
+
+	e := DecryptingEnv(Named(".env", MapEnv{
+		"DB_USER": "bob",
+		"DB_PASS": pass,
+		"BAD":     EncryptedPrefix + "AAAA",
+	}), key)
+
+	// Reader
+	r := New(e)
+	assert.Equal(t, "bob", r.Get("DB_USER"), "unexpected DB_USER")
+	assert.Equal(t, "hunter2", r.Get("DB_PASS"), "unexpected DB_PASS")
+	assert.Equal(t, "default", r.Get("BAD", "default"), "unexpected BAD")
+	assert.Equal(t, []string{"BAD", "DB_PASS", "DB_USER"}, Keys(e, ""), "unexpected keys")
+	assert.Equal(t, ".env", SourceName(e), "unexpected name")
+
+	// Bind
+	v := struct {
+		DBUser string `env:"DB_USER"`
+		DBPass Secret `env:"DB_PASS"`
+	}{}
+	var rep Report
+	require.NoError(t, BindWith(&v, Chain(MapEnv{}, e), WithReport(&rep)), "bind failed")
+	assert.Equal(t, "bob", v.DBUser, "unexpected DBUser")
+	assert.Equal(t, Secret("hunter2"), v.DBPass, "unexpected DBPass")
+	fs, _ := rep.Get("DBPass")
+	assert.Equal(t, ".env", fs.Source, "unexpected source")
+
+	// values can't be swapped between variables
+	swapped := DecryptingEnv(MapEnv{"ADMIN_TOKEN": pass}, key)
+	_, ok, err := swapped.(FallibleEnv).LookupErr("ADMIN_TOKEN")
+	assert.False(t, ok, "swapped value accepted")
+	assert.Equal(t, ErrDecrypt, err, "unexpected error")
+
+	// decryption errors are returned by Bind, even from a chain
+	w := struct{ Bad string }{}
+	err = Bind(&w, Chain(MapEnv{}, PrefixEnv(e, "")))
+	assert.EqualError(t, err, "BAD: cannot decrypt value", "unexpected error")
+	assert.True(t, errors.Is(err, ErrDecrypt), "unexpected error")
+}
+
+// Decrypt values when binding a struct.
+func ExampleDecryptingEnv() {
+	key, err := GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+
+	// encrypted values can be committed to a .env file
+	ciphertext, err := Encrypt(key, "DB_PASS", "hunter2")
+	if err != nil {
+		panic(err)
+	}
+	dotenv, err := ParseDotenv(strings.NewReader("DB_USER=bob\nDB_PASS=" + ciphertext))
+	if err != nil {
+		panic(err)
+	}
+
+	cfg := struct {
+		DBUser string `env:"DB_USER"`
+		DBPass Secret `env:"DB_PASS"`
+	}{}
+	if err := Bind(&cfg, DecryptingEnv(dotenv, key)); err != nil {
+		panic(err)
+	}
+
+	fmt.Println(cfg.DBUser)
+	fmt.Println(string(cfg.DBPass))
+
+	// Output:
+	// bob
+	// hunter2
+}
@@ -28,6 +28,40 @@ Read environment variables with the Get* functions:
 	// Duration with fallback
 	d = env.GetDuration("NON_EXISTENT_VAR", time.Minute * 120) // -> 2h0m
 
+Lists are separated by commas, as for slice fields populated by Bind():
+
+	// HOSTS=a.example.com,b.example.com
+	hosts := env.GetStrings("HOSTS")
+	// LABELS=env=prod,team=ops
+	labels := env.GetStringMap("LABELS")
+
+The Get* functions return the fallback if a value is invalid. Use the
+Lookup* functions to find out whether a variable is set and get an error
+if its value is invalid:
+
+	// PORT=80a
+	i, ok, err := env.LookupInt("PORT") // -> 0, true, *ParseError
+
+GetAs() reads any type Bind() supports, including types whose parsers
+are registered with RegisterParser():
+
+	port, err := env.GetAs[uint16](r, "PORT", 80)
+
+The Must* functions are for required variables read at startup. They call
+MustHandler, which panics by default, if a variable is unset or invalid:
+
+	port := env.MustGetInt("PORT")
+
+A Reader returned by Collecting() records every invalid value, and every
+unset variable read without a fallback, and returns them all from Err():
+
+	r := env.Collecting()
+	host := r.Get("HOST")
+	port := r.GetInt("PORT", 80)
+	if err := r.Err(); err != nil {
+		// handle error...
+	}
+
 
 Populating structs
 
@@ -46,6 +80,10 @@ Populate a struct from the environment by passing it to Bind():
 	fmt.Println(o.Hostname) // -> value of HOSTNAME environment variable
 	fmt.Println(o.Port)     // -> value of PORT environment variable
 
+LoadAs() returns a populated struct:
+
+	o, err := env.LoadAs[options]()
+
 Use tags to specify a variable name or ignore a field:
 
 	type options {
@@ -54,6 +92,17 @@ Use tags to specify a variable name or ignore a field:
 		Online   bool `env:"-"` // ignored
 	}
 
+Slices of structs are populated from indexed variables. A field
+Upstreams []Upstream is read from UPSTREAMS_0_HOST, UPSTREAMS_0_PORT,
+UPSTREAMS_1_HOST etc. Items are read until one is missing, unless
+UPSTREAMS_COUNT specifies the number of items.
+
+Maps of structs are populated from variables that contain the map key.
+A field DB map[string]DBConfig is read from DB_PRIMARY_HOST,
+DB_REPLICA_HOST etc. (keys PRIMARY and REPLICA). The keys are found by
+listing the Env's variables, so the Env must be able to enumerate them,
+as System and MapEnv can.
+
 
 Dumping structs
 
@@ -77,6 +126,12 @@ Dump a struct to a map[string]string by passing it to Dump():
     fmt.Println(vars["HOSTNAME"]) // -> www.example.com
     fmt.Println(vars["PORT"])     // -> 22
 
+Export() sets a struct's variables in the environment, and Unexport()
+removes them again. ExportTo() and UnexportFrom() do the same for any Env
+that implements Setter, such as MapEnv. If a variable can't be set, the
+previous values are restored. ExportScoped() returns a function that
+restores them, for temporary overrides.
+
 
 Tags
 
@@ -96,6 +151,33 @@ Add `env:"VARNAME"` to bind a field to the variable VARNAME:
 		APIKey   string `env:"APP_SECRET"` // default = API_KEY
 	}
 
+Add `enum:"name:value,..."` to accept symbolic names for a field's values.
+Names are matched case-insensitively, and Dump() emits them back:
+
+	type options {
+		Mode int `enum:"off:0,on:1,auto:2"` // MODE=Auto -> 2
+	}
+
+Use RegisterEnum() to register names for all fields of a given type.
+
+Options follow the variable name, separated by commas. The name may be
+empty to use the default. "required" makes Bind() return an error if the
+variable is unset or empty, and "secret" marks a field whose value must
+not be shown, e.g. in a Report:
+
+	type options {
+		Password string `env:",required,secret"`
+	}
+
+Fields of type Secret are also secrets. A Secret prints as "********",
+Bind() errors never contain the values of secret fields, and Dump()
+masks them if passed the MaskSecrets option.
+
+Add `desc:"..."` to describe a field in the output of Example(), which
+generates a .env.example file for a struct, and Usage(), which prints a
+table of the variables a struct is populated from. Drift() compares a .env file
+with a struct and lists missing and unknown variables.
+
 
 Customisation
 
@@ -108,6 +190,36 @@ structs from a source other than environment variables.
 See _examples/docopt to see a custom Env implementation used to
 populate a struct from docopt command-line options.
 
+The package-level functions, and Bind() if no Env is passed, read from
+the Env set with SetDefault(), the program's environment unless changed.
+Default() returns a Reader for it:
+
+	defer env.SetDefault(env.SetDefault(env.Chain(dotenv, env.System)))
+
+Envs can be layered with Chain(), which returns the value from the first
+Env in which a variable is set, or combined with Merge(), which joins the
+values from all of them. PrefixEnv() scopes an Env to variables with a
+given prefix.
+
+ParseDotenv() and LoadDotenv() read .env files into a MapEnv, and Load()
+adds the variables in .env files to the environment. References such as
+${HOME} are only expanded if the Interpolate option is passed.
+
+DecryptingEnv() decrypts values encrypted with Encrypt(), so .env files
+containing secrets can be committed. The envcrypt command in cmd/envcrypt
+generates keys and encrypts values.
+
+ResolvingEnv() replaces references such as file:///run/secrets/db with
+the values they point to, using the Resolvers registered with
+RegisterResolver(). Values with other schemes are errors, unless the
+scheme is passed to ResolvingEnv() to be ignored, e.g. "https".
+
+Label Envs with Named() and pass WithReport() to BindWith() to find out
+which Env each field's value came from, or whether it kept its default.
+
+Encode() writes a struct's variables as a .env file, POSIX or fish shell
+script, or docker env file. Write() does the same for a map of variables.
+
 You can also customise the map keys used when dumping a struct by passing
 VarNameFunc to Dump().
 
@@ -0,0 +1,466 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// DotenvError is returned by ParseDotenv and LoadDotenv if a file
+// contains a syntax error.
+type DotenvError struct {
+	File string // path of the file (empty for ParseDotenv)
+	Line int    // line number of the error, starting at 1
+	Err  error  // what is wrong
+}
+
+// Error implements error.
+func (err *DotenvError) Error() string {
+	if err.File == "" {
+		return fmt.Sprintf("line %d: %v", err.Line, err.Err)
+	}
+	return fmt.Sprintf("%s:%d: %v", err.File, err.Line, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *DotenvError) Unwrap() error { return err.Err }
+
+// ParseDotenv reads variables in dotenv format from r. The format is:
+//
+//	# comment
+//	KEY=value
+//	export KEY=value           # "export" is ignored
+//	KEY='literal $value'       # no escapes or interpolation
+//	KEY=`literal $value`       # same as single quotes
+//	KEY="line 1\nline 2"       # escapes \n \r \t \" \\ \$
+//	KEY="spans
+//	several lines"
+//
+// By default, "$" has no special meaning, and the result depends only on
+// the contents of r. Pass the Interpolate option to expand references to
+// other variables in unquoted and double-quoted values:
+//
+//	KEY=${OTHER}/bin           # interpolation
+//	KEY=${OTHER:-default}      # default if OTHER is unset or empty
+//
+// Syntax errors are returned as a *DotenvError.
+func ParseDotenv(r io.Reader, opt ...DotenvOption) (MapEnv, error) {
+	return parseDotenv(r, "", MapEnv{}, opt...)
+}
+
+// DotenvOption is a configuration option to ParseDotenv, LoadDotenvWith
+// and LoadWith.
+type DotenvOption func(p *dotenvParser)
+
+// Interpolate enables the expansion of $VAR and ${VAR} references in
+// dotenv files. Variables defined earlier in the file (or in earlier
+// files) take precedence over those in env, which may be nil to only
+// expand variables defined in the files. Unset variables expand to an
+// empty string.
+//
+// Pass System to reference the process environment:
+//
+//	vars, err := env.ParseDotenv(r, env.Interpolate(env.System))
+func Interpolate(env Env) DotenvOption {
+	return func(p *dotenvParser) {
+		p.interpolate = true
+		p.env = env
+	}
+}
+
+// LoadDotenv reads variables from dotenv files (see ParseDotenv). If no
+// paths are given, it reads ".env" in the working directory. Variables in
+// later files override those in earlier ones.
+func LoadDotenv(paths ...string) (MapEnv, error) {
+	return LoadDotenvWith(paths)
+}
+
+// LoadDotenvWith is LoadDotenv with options. With Interpolate, files may
+// reference variables set by earlier files.
+func LoadDotenvWith(paths []string, opt ...DotenvOption) (MapEnv, error) {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+
+	vars := MapEnv{}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		_, err = parseDotenv(f, path, vars, opt...)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vars, nil
+}
+
+// Load reads variables from dotenv files with LoadDotenv and sets them in
+// the process environment. Variables that are already set, even to an
+// empty string, are not overridden. If a variable can't be set, those
+// already set are unset again, so the environment is left unchanged.
+func Load(paths ...string) error {
+	return LoadWith(paths)
+}
+
+// LoadWith is Load with options. With Interpolate, references to
+// variables that are already set resolve to their current values, not
+// those in the files, as the files don't override them.
+func LoadWith(paths []string, opt ...DotenvOption) error {
+	opt = append(opt[:len(opt):len(opt)], func(p *dotenvParser) { p.set = System })
+	vars, err := LoadDotenvWith(paths, opt...)
+	if err != nil {
+		return err
+	}
+
+	s := &snapshot{target: System.(Setter)}
+	for _, k := range sortedKeys(vars) {
+		if _, ok := os.LookupEnv(k); ok {
+			continue
+		}
+		s.save(k)
+		if err := os.Setenv(k, vars[k]); err != nil {
+			_ = s.restore()
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDotenv reads variables from r and adds them to vars. With the
+// Interpolate option, references are resolved against vars, then env.
+func parseDotenv(r io.Reader, file string, vars MapEnv, opt ...DotenvOption) (MapEnv, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotenvParser{
+		data: []rune(strings.Replace(string(data), "\r\n", "\n", -1)),
+		line: 1,
+		file: file,
+		vars: vars,
+	}
+	for _, o := range opt {
+		o(p)
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// end-of-input marker returned by dotenvParser.peek.
+const eof rune = -1
+
+// dotenvParser parses the contents of a dotenv file.
+type dotenvParser struct {
+	data []rune
+	pos  int
+	line int
+	file string
+	vars MapEnv
+
+	interpolate bool // whether to expand variable references
+	env         Env  // Env to resolve references against; may be nil
+	set         Env  // if non-nil, variables that the files don't override
+}
+
+func (p *dotenvParser) parse() error {
+	for {
+		p.skip(" \t\n")
+		switch p.peek() {
+		case eof:
+			return nil
+		case '#':
+			p.skipComment()
+			continue
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		p.vars[key] = value
+	}
+}
+
+// parseKey reads "[export] KEY =".
+func (p *dotenvParser) parseKey() (string, error) {
+	key := p.readName()
+	if key == "export" && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skip(" \t")
+		key = p.readName()
+	}
+	if key == "" {
+		return "", p.errorf("invalid character %q in variable name", p.peek())
+	}
+
+	p.skip(" \t")
+	if p.peek() != '=' {
+		return "", p.errorf("expected '=' after %s", key)
+	}
+	p.next()
+	p.skip(" \t")
+	return key, nil
+}
+
+// parseValue reads a value and the rest of its (last) line.
+func (p *dotenvParser) parseValue() (string, error) {
+	var (
+		value string
+		err   error
+	)
+	switch p.peek() {
+	case '\'', '`':
+		value, err = p.readLiteral()
+	case '"':
+		value, err = p.readQuoted()
+	default:
+		return p.readUnquoted()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	p.skip(" \t")
+	switch c := p.peek(); c {
+	case '#':
+		p.skipComment()
+	case '\n', eof:
+	default:
+		return "", p.errorf("unexpected character %q after value", c)
+	}
+	return value, nil
+}
+
+// readLiteral reads a single- or backtick-quoted value.
+func (p *dotenvParser) readLiteral() (string, error) {
+	var (
+		quote = p.next()
+		line  = p.line
+		sb    strings.Builder
+	)
+	for {
+		c := p.next()
+		switch c {
+		case eof:
+			return "", p.errorAt(line, "unterminated quoted value")
+		case quote:
+			return sb.String(), nil
+		}
+		sb.WriteRune(c)
+	}
+}
+
+// readQuoted reads a double-quoted value, expanding escapes and variables.
+func (p *dotenvParser) readQuoted() (string, error) {
+	var (
+		line = p.line
+		sb   strings.Builder
+	)
+	p.next()
+	for {
+		c := p.next()
+		switch c {
+		case eof:
+			return "", p.errorAt(line, "unterminated quoted value")
+		case '"':
+			return sb.String(), nil
+		case '$':
+			if !p.interpolate {
+				break
+			}
+			s, err := p.readReference()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(s)
+			continue
+		case '\\':
+			switch e := p.next(); e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case '"', '\\', '$':
+				c = e
+			case eof:
+				return "", p.errorAt(line, "unterminated quoted value")
+			default:
+				sb.WriteRune('\\')
+				c = e
+			}
+		}
+		sb.WriteRune(c)
+	}
+}
+
+// readUnquoted reads an unquoted value up to the end of the line or a
+// comment. Surrounding whitespace is removed.
+func (p *dotenvParser) readUnquoted() (string, error) {
+	var sb strings.Builder
+	for {
+		c := p.peek()
+		switch {
+		case c == eof || c == '\n':
+			return strings.TrimSpace(sb.String()), nil
+		case c == '#' && (p.data[p.pos-1] == ' ' || p.data[p.pos-1] == '\t'):
+			p.skipComment()
+			return strings.TrimSpace(sb.String()), nil
+		}
+		p.next()
+		if c == '$' && p.interpolate {
+			s, err := p.readReference()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(s)
+			continue
+		}
+		sb.WriteRune(c)
+	}
+}
+
+// readReference reads a variable reference following a '$' and returns
+// its value. A '$' that isn't followed by a name is returned as is.
+func (p *dotenvParser) readReference() (string, error) {
+	if p.peek() != '{' {
+		name := p.readName()
+		if name == "" {
+			return "$", nil
+		}
+		return p.lookup(name), nil
+	}
+
+	line := p.line
+	p.next()
+	var sb strings.Builder
+	for {
+		c := p.next()
+		switch c {
+		case eof, '\n':
+			return "", p.errorAt(line, "unterminated variable reference")
+		case '}':
+			ref := sb.String()
+			name, def := ref, ""
+			if i := strings.Index(ref, ":-"); i >= 0 {
+				name, def = ref[:i], ref[i+2:]
+			}
+			if !isName(name) {
+				return "", p.errorAt(line, fmt.Sprintf("invalid variable reference ${%s}", ref))
+			}
+			if s := p.lookup(name); s != "" {
+				return s, nil
+			}
+			return def, nil
+		}
+		sb.WriteRune(c)
+	}
+}
+
+// lookup returns the value of a referenced variable.
+func (p *dotenvParser) lookup(name string) string {
+	if p.set != nil {
+		if s, ok := p.set.Lookup(name); ok {
+			return s
+		}
+	}
+	if s, ok := p.vars[name]; ok {
+		return s
+	}
+	if p.env == nil {
+		return ""
+	}
+	s, _ := p.env.Lookup(name)
+	return s
+}
+
+// readName reads a variable name.
+func (p *dotenvParser) readName() string {
+	start := p.pos
+	for isNameRune(p.peek(), p.pos == start) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// peek returns the next rune without consuming it.
+func (p *dotenvParser) peek() rune {
+	if p.pos >= len(p.data) {
+		return eof
+	}
+	return p.data[p.pos]
+}
+
+// next consumes and returns the next rune.
+func (p *dotenvParser) next() rune {
+	c := p.peek()
+	if c != eof {
+		p.pos++
+		if c == '\n' {
+			p.line++
+		}
+	}
+	return c
+}
+
+// skip consumes any of the runes in chars.
+func (p *dotenvParser) skip(chars string) {
+	for c := p.peek(); c != eof && strings.ContainsRune(chars, c); c = p.peek() {
+		p.next()
+	}
+}
+
+// skipComment consumes the rest of the line.
+func (p *dotenvParser) skipComment() {
+	for c := p.peek(); c != eof && c != '\n'; c = p.peek() {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotenvParser) errorAt(line int, msg string) error {
+	return &DotenvError{File: p.file, Line: line, Err: errors.New(msg)}
+}
+
+// isName returns true if s is a valid variable name.
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if !isNameRune(c, i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNameRune returns true if c may appear in a variable name. Names may
+// not start with a digit.
+func isNameRune(c rune, first bool) bool {
+	switch {
+	case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		return true
+	case c >= '0' && c <= '9':
+		return !first
+	}
+	return false
+}
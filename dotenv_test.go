@@ -0,0 +1,217 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotenv(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+	require.NoError(t, os.Setenv("HOME", "/home/test"), "setenv failed")
+
+	tests := []struct {
+		in string
+		x  MapEnv
+	}{
+		{"", MapEnv{}},
+		{"# comment\n\n", MapEnv{}},
+		{"KEY=value", MapEnv{"KEY": "value"}},
+		{"KEY = value  \n", MapEnv{"KEY": "value"}},
+		{"KEY=", MapEnv{"KEY": ""}},
+		{"KEY=value # comment", MapEnv{"KEY": "value"}},
+		{"KEY=value#notcomment", MapEnv{"KEY": "value#notcomment"}},
+		{"KEY=#value", MapEnv{"KEY": "#value"}},
+		{"export KEY=value", MapEnv{"KEY": "value"}},
+		{"export=value", MapEnv{"export": "value"}},
+		{"A=1\r\nB=2\r\n", MapEnv{"A": "1", "B": "2"}},
+		{`KEY='a $HOME \n # b'`, MapEnv{"KEY": `a $HOME \n # b`}},
+		{"KEY=`a 'b' \"c\"`", MapEnv{"KEY": `a 'b' "c"`}},
+		{`KEY="a\tb\nc\"d\\e\$f\g"`, MapEnv{"KEY": "a\tb\nc\"d\\e$f\\g"}},
+		{`KEY="value" # comment`, MapEnv{"KEY": "value"}},
+		{"KEY=\"line 1\nline 2\"\nNEXT=x", MapEnv{"KEY": "line 1\nline 2", "NEXT": "x"}},
+		{"KEY='line 1\nline 2'", MapEnv{"KEY": "line 1\nline 2"}},
+		{"KEY=a\nKEY=b", MapEnv{"KEY": "b"}},
+		// no interpolation by default
+		{"PASS=pa$word", MapEnv{"PASS": "pa$word"}},
+		{`KEY="${HOME}bin"`, MapEnv{"KEY": "${HOME}bin"}},
+		{"A=a\nB=${A}b", MapEnv{"A": "a", "B": "${A}b"}},
+		{"KEY=${A", MapEnv{"KEY": "${A"}},
+	}
+
+	for _, td := range tests {
+		td := td
+		t.Run(td.in, func(t *testing.T) {
+			v, err := ParseDotenv(strings.NewReader(td.in))
+			require.NoError(t, err, "parse failed")
+			assert.Equal(t, td.x, v, "unexpected result")
+		})
+	}
+}
+
+func TestParseDotenv_interpolate(t *testing.T) {
+	e := MapEnv{"HOME": "/home/test"}
+
+	tests := []struct {
+		in  string
+		env Env
+		x   MapEnv
+	}{
+		{"KEY=$HOME/bin", e, MapEnv{"KEY": "/home/test/bin"}},
+		{`KEY="${HOME}bin"`, e, MapEnv{"KEY": "/home/testbin"}},
+		{"KEY='$HOME'", e, MapEnv{"KEY": "$HOME"}},
+		{`KEY="\$HOME"`, e, MapEnv{"KEY": "$HOME"}},
+		{"A=a\nB=${A}b", e, MapEnv{"A": "a", "B": "ab"}},
+		{"HOME=/x\nB=$HOME", e, MapEnv{"HOME": "/x", "B": "/x"}},
+		{"KEY=${UNSET}", e, MapEnv{"KEY": ""}},
+		{"KEY=${UNSET:-default}", e, MapEnv{"KEY": "default"}},
+		{"KEY=${HOME:-default}", e, MapEnv{"KEY": "/home/test"}},
+		{"KEY=$ 5$", e, MapEnv{"KEY": "$ 5$"}},
+		// file variables only
+		{"KEY=$HOME/bin", nil, MapEnv{"KEY": "/bin"}},
+		{"A=a\nB=${A}b", nil, MapEnv{"A": "a", "B": "ab"}},
+	}
+
+	for _, td := range tests {
+		td := td
+		t.Run(td.in, func(t *testing.T) {
+			v, err := ParseDotenv(strings.NewReader(td.in), Interpolate(td.env))
+			require.NoError(t, err, "parse failed")
+			assert.Equal(t, td.x, v, "unexpected result")
+		})
+	}
+}
+
+func TestParseDotenv_invalid(t *testing.T) {
+	tests := []struct {
+		in, err string
+	}{
+		{"KEY", "line 1: expected '=' after KEY"},
+		{"\n\n1KEY=x", `line 3: invalid character '1' in variable name`},
+		{"A=1\nKEY=\"value\n\n", "line 2: unterminated quoted value"},
+		{"KEY='value", "line 1: unterminated quoted value"},
+		{`KEY="value" x`, `line 1: unexpected character 'x' after value`},
+		{"A=1\nKEY=${A", "line 2: unterminated variable reference"},
+		{"KEY=${A B}", "line 1: invalid variable reference ${A B}"},
+	}
+
+	for _, td := range tests {
+		td := td
+		t.Run(td.in, func(t *testing.T) {
+			_, err := ParseDotenv(strings.NewReader(td.in), Interpolate(nil))
+			require.Error(t, err, "accepted invalid input")
+			assert.Equal(t, td.err, err.Error(), "unexpected error")
+			_, ok := err.(*DotenvError)
+			assert.True(t, ok, "not a *DotenvError")
+		})
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	dir, err := ioutil.TempDir("", "env-")
+	require.NoError(t, err, "create tempdir")
+	defer os.RemoveAll(dir)
+
+	var (
+		base  = filepath.Join(dir, "base.env")
+		local = filepath.Join(dir, "local.env")
+		bad   = filepath.Join(dir, "bad.env")
+	)
+	require.NoError(t, ioutil.WriteFile(base, []byte("HOST=example.com\nPORT=80\n"), 0600), "write file")
+	require.NoError(t, ioutil.WriteFile(local, []byte("PORT=8080\nURL=http://${HOST}:${PORT}\n"), 0600), "write file")
+	require.NoError(t, ioutil.WriteFile(bad, []byte("\nPORT\n"), 0600), "write file")
+
+	v, err := LoadDotenv(base, local)
+	require.NoError(t, err, "load failed")
+	assert.Equal(t, MapEnv{"HOST": "example.com", "PORT": "8080", "URL": "http://${HOST}:${PORT}"}, v, "unexpected result")
+
+	v, err = LoadDotenvWith([]string{base, local}, Interpolate(nil))
+	require.NoError(t, err, "load failed")
+	assert.Equal(t, MapEnv{"HOST": "example.com", "PORT": "8080", "URL": "http://example.com:8080"}, v, "unexpected result")
+
+	_, err = LoadDotenv(base, bad)
+	assert.EqualError(t, err, bad+":2: expected '=' after PORT", "unexpected error")
+
+	_, err = LoadDotenv(filepath.Join(dir, "missing.env"))
+	assert.True(t, os.IsNotExist(err), "unexpected error")
+
+	// Load doesn't override existing variables
+	require.NoError(t, os.Setenv("PORT", ""), "setenv failed")
+	require.NoError(t, LoadWith([]string{base, local}, Interpolate(System)), "load failed")
+	assert.Equal(t, "example.com", os.Getenv("HOST"), "unexpected HOST")
+	assert.Equal(t, "", os.Getenv("PORT"), "unexpected PORT")
+	assert.Equal(t, "http://example.com:", os.Getenv("URL"), "unexpected URL") // PORT is set
+
+	// references to existing variables use their values
+	os.Clearenv()
+	require.NoError(t, os.Setenv("HOST", "b"), "setenv failed")
+	require.NoError(t, LoadWith([]string{base, local}, Interpolate(nil)), "load failed")
+	assert.Equal(t, "b", os.Getenv("HOST"), "unexpected HOST")
+	assert.Equal(t, "http://b:8080", os.Getenv("URL"), "unexpected URL")
+
+	assert.Error(t, Load(bad), "loaded invalid file")
+}
+
+func TestLoad_rollback(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	dir, err := ioutil.TempDir("", "env-")
+	require.NoError(t, err, "create tempdir")
+	defer os.RemoveAll(dir)
+
+	// os.Setenv rejects values containing NUL bytes
+	path := filepath.Join(dir, ".env")
+	require.NoError(t, ioutil.WriteFile(path, []byte("A=1\nB=2\nC=\"x\x00y\"\nD=4\n"), 0600), "write file")
+	require.NoError(t, os.Setenv("B", "old"), "setenv failed")
+
+	assert.Error(t, Load(path), "loaded invalid value")
+	assert.Equal(t, []string{"B=old"}, os.Environ(), "environment changed")
+}
+
+// Use a dotenv file as an Env.
+func ExampleParseDotenv() {
+	data := `
+# API settings
+export API_URL=https://api.example.com
+API_KEY='abc$123'
+MOTD="Hello,
+world!"
+`
+
+	e, err := ParseDotenv(strings.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+
+	cfg := struct {
+		APIURL string `env:"API_URL"`
+		APIKey string
+		MOTD   string
+	}{}
+	if err := Bind(&cfg, e); err != nil {
+		panic(err)
+	}
+	fmt.Println(cfg.APIURL)
+	fmt.Println(cfg.APIKey)
+	fmt.Println(cfg.MOTD)
+
+	// Output:
+	// https://api.example.com
+	// abc$123
+	// Hello,
+	// world!
+}
@@ -7,16 +7,65 @@ import (
 	"encoding"
 	"errors"
 	"fmt"
-	"os"
+	"math/big"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 )
 
 // sentinel error returned by toString to indicate that Dump should try
 // further methods to convert a value to a string.
 var errUnknownType = errors.New("unknown type")
 
+// function that formats a type's values as a string.
+type formatFunc func(rv reflect.Value) (string, error)
+
+// Functions to format types that don't implement encoding.TextMarshaler
+// or fmt.Stringer. Pointer types are dereferenced if no function is
+// registered for the pointer type itself.
+var typeFormatters = map[reflect.Type]formatFunc{
+	reflect.TypeOf(url.URL{}): func(rv reflect.Value) (string, error) {
+		u := rv.Interface().(url.URL)
+		return u.String(), nil
+	},
+	reflect.TypeOf(&big.Float{}): func(rv reflect.Value) (string, error) {
+		return rv.Interface().(*big.Float).Text('g', -1), nil
+	},
+	reflect.TypeOf(&big.Rat{}): func(rv reflect.Value) (string, error) {
+		return rv.Interface().(*big.Rat).RatString(), nil
+	},
+	secretType: func(rv reflect.Value) (string, error) {
+		return rv.String(), nil
+	},
+	reflect.TypeOf(&template.Template{}): func(rv reflect.Value) (string, error) {
+		return templateSource(rv.Interface().(*template.Template)), nil
+	},
+}
+
+// templateSource returns source text that Bind parses into a template
+// equivalent to t. Associated templates, e.g. those created by {{define}},
+// are prepended as {{define}} blocks, sorted by name.
+func templateSource(t *template.Template) string {
+	var (
+		b     strings.Builder
+		assoc = t.Templates()
+	)
+	sort.Slice(assoc, func(i, j int) bool { return assoc[i].Name() < assoc[j].Name() })
+	for _, a := range assoc {
+		if a.Name() == t.Name() || a.Tree == nil || a.Tree.Root == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "{{define %q}}%s{{end}}", a.Name(), a.Tree.Root.String())
+	}
+	if t.Tree != nil && t.Tree.Root != nil {
+		b.WriteString(t.Tree.Root.String())
+	}
+	return b.String()
+}
+
 // DumpOption is a configuration option to Dump.
 type DumpOption func(d *dumper)
 
@@ -39,36 +88,164 @@ func VarNameFunc(fun func(string) string) DumpOption {
 // By default, the names (map keys) of the variables are generated using
 // VarName. Pass the VarNameFunc option to generate custom keys.
 func Dump(v interface{}, opt ...DumpOption) (map[string]string, error) {
-	d := &dumper{
-		nameFunc: VarName,
-	}
-	for _, o := range opt {
-		o(d)
-	}
-	return d.dump(v)
+	return newDumper(opt...).dump(v)
 }
 
 // Export extracts a struct's fields' values (via Dump) and exports them to the
-// environment (via os.Setenv). It accepts the same options as Dump.
+// environment (via os.Setenv). It accepts the same options as Dump, except
+// MaskSecrets: the real values of secrets are always exported.
+//
+// If a variable can't be set, the variables already set are restored to
+// their previous values, so the environment is left unchanged.
 func Export(v interface{}, opt ...DumpOption) error {
-	vars, err := Dump(v, opt...)
+	return ExportTo(System.(Setter), v, opt...)
+}
+
+// ExportTo extracts a struct's fields' values (via Dump) and sets them in
+// target, e.g. a MapEnv. It accepts the same options as Export, and also
+// restores target's previous values if a variable can't be set.
+func ExportTo(target Setter, v interface{}, opt ...DumpOption) error {
+	_, err := exportTo(target, v, opt...)
+	return err
+}
+
+// ExportScoped exports a struct's variables like Export, and returns a
+// function that restores the variables' previous values, unsetting those
+// that were previously unset. It is intended for temporary overrides:
+//
+//	restore, err := env.ExportScoped(cfg)
+//	if err != nil {
+//		// handle error...
+//	}
+//	defer restore()
+//
+// The environment is shared by the whole process, so changes made by
+// other code between the export and the restore may be overwritten.
+func ExportScoped(v interface{}, opt ...DumpOption) (restore func(), err error) {
+	s, err := exportTo(System.(Setter), v, opt...)
+	if err != nil {
+		return nil, err
+	}
+	return func() { _ = s.restore() }, nil
+}
+
+// exportTo implements ExportTo and returns a snapshot of target's values
+// before the export. If a variable can't be set, the snapshot is restored.
+func exportTo(target Setter, v interface{}, opt ...DumpOption) (*snapshot, error) {
+	d := newDumper(opt...)
+	d.mask = false
+	vars, err := d.dump(v)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &snapshot{target: target}
+	for _, k := range sortedKeys(vars) {
+		s.save(k)
+		if err := target.Set(k, vars[k]); err != nil {
+			_ = s.restore()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// snapshot holds the previous values of variables in a Setter.
+type snapshot struct {
+	target Setter
+	vars   []savedVar
+}
+
+// savedVar is the previous value of a variable.
+type savedVar struct {
+	key, value string
+	ok         bool // whether variable was set
+}
+
+// save records the current value of key.
+func (s *snapshot) save(key string) {
+	value, ok := s.target.Lookup(key)
+	s.vars = append(s.vars, savedVar{key, value, ok})
+}
+
+// restore resets the saved variables to their recorded values in reverse
+// order. It returns the first error, but attempts to restore every variable.
+func (s *snapshot) restore() error {
+	var err error
+	for i := len(s.vars) - 1; i >= 0; i-- {
+		var (
+			v = s.vars[i]
+			e error
+		)
+		if v.ok {
+			e = s.target.Set(v.key, v.value)
+		} else {
+			e = s.target.Unset(v.key)
+		}
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Unexport removes a struct's variables, i.e. those Export would set,
+// from the environment. It accepts the same options as Export.
+func Unexport(v interface{}, opt ...DumpOption) error {
+	return UnexportFrom(System.(Setter), v, opt...)
+}
+
+// UnexportFrom removes a struct's variables from target. It accepts the
+// same options as Export. Variables of zero-value fields are removed even
+// if IgnoreZeroValues is passed.
+func UnexportFrom(target Setter, v interface{}, opt ...DumpOption) error {
+	d := newDumper(opt...)
+	d.noZero = false
+	vars, err := d.dump(v)
 	if err != nil {
 		return err
 	}
-	for k, v := range vars {
-		if err := os.Setenv(k, v); err != nil {
+	for _, k := range sortedKeys(vars) {
+		if err := target.Unset(k); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// sortedKeys returns the keys of vars in alphabetical order.
+func sortedKeys(vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // dumper reads a struct's fields and returns them as a map[string]string.
 type dumper struct {
 	noZero   bool
+	mask     bool
 	nameFunc func(string) string
 }
 
+func newDumper(opt ...DumpOption) *dumper {
+	d := &dumper{nameFunc: VarName}
+	for _, o := range opt {
+		o(d)
+	}
+	return d
+}
+
+// set adds a variable to vars.
+func (d *dumper) set(vars map[string]string, key, value string, field reflect.StructField) {
+	if d.mask && value != "" && isSecret(field) {
+		value = Masked
+	}
+	vars[key] = value
+}
+
 func (d *dumper) dump(v interface{}) (map[string]string, error) {
 	vars := map[string]string{}
 	rv := reflect.ValueOf(v)
@@ -80,14 +257,23 @@ func (d *dumper) dump(v interface{}) (map[string]string, error) {
 		return nil, ErrNotStruct
 	}
 
+	if err := d.dumpStruct(rv, "", vars); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// dumpStruct adds the fields of struct rv to vars. The names of all
+// variables are prefixed with prefix.
+func (d *dumper) dumpStruct(rv reflect.Value, prefix string, vars map[string]string) error {
 	rvType := rv.Type()
 
 	for i := 0; i < rvType.NumField(); i++ {
 		var (
-			val   = rv.Field(i)
-			field = rvType.Field(i)
-			name  = field.Name
-			key   = field.Tag.Get("env")
+			val    = rv.Field(i)
+			field  = rvType.Field(i)
+			name   = field.Name
+			key, _ = parseTag(field)
 		)
 
 		if d.noZero && val.IsZero() {
@@ -102,30 +288,61 @@ func (d *dumper) dump(v interface{}) (map[string]string, error) {
 		if key == "" {
 			key = d.nameFunc(name)
 		}
+		key = prefix + key
 
 		if val.Kind() == reflect.Ptr && val.IsNil() {
-			vars[key] = ""
+			d.set(vars, key, "", field)
+			continue
+		}
+
+		enum, err := fieldEnum(field)
+		if err != nil {
+			return err
+		}
+		if enum != nil {
+			s, err := dumpEnum(val, enum)
+			if err != nil {
+				return err
+			}
+			if s == "" && d.noZero {
+				continue
+			}
+			d.set(vars, key, s, field)
+			continue
+		}
+
+		if isStructSlice(val.Type()) {
+			if err := d.dumpStructSlice(val, key, vars); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if isStructMap(val.Type()) {
+			if err := d.dumpStructMap(val, key, vars); err != nil {
+				return err
+			}
 			continue
 		}
 
-		if val.Kind() == reflect.Slice {
+		if val.Kind() == reflect.Slice && !hasTypeParser(val.Type()) {
 			s, err := dumpSlice(val)
 			if err != nil {
-				return nil, err
+				return err
 			}
 			if s == "" && d.noZero {
 				continue
 			}
-			vars[key] = s
+			d.set(vars, key, s, field)
 			continue
 		}
 
 		s, err := toString(val)
 		if err != nil && err != errUnknownType {
-			return nil, err
+			return err
 		}
 		if err != errUnknownType {
-			vars[key] = s
+			d.set(vars, key, s, field)
 			continue
 		}
 
@@ -134,18 +351,47 @@ func (d *dumper) dump(v interface{}) (map[string]string, error) {
 		}
 
 		if val.Kind() == reflect.Struct {
-			m, err := d.dump(val.Interface())
-			if err != nil {
-				return nil, err
-			}
-			for k, v := range m {
-				vars[k] = v
+			if err := d.dumpStruct(val, prefix, vars); err != nil {
+				return err
 			}
 			continue
 		}
 	}
 
-	return vars, nil
+	return nil
+}
+
+// dumpStructSlice adds the items of a slice of structs to vars as
+// indexed variables, e.g. KEY_0_FIELD, KEY_1_FIELD, plus KEY_COUNT.
+func (d *dumper) dumpStructSlice(rv reflect.Value, key string, vars map[string]string) error {
+	d.set(vars, key+"_COUNT", strconv.Itoa(rv.Len()), reflect.StructField{})
+	for i := 0; i < rv.Len(); i++ {
+		item := reflect.Indirect(rv.Index(i))
+		if !item.IsValid() {
+			continue
+		}
+		if err := d.dumpStruct(item, fmt.Sprintf("%s_%d_", key, i), vars); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dumpStructMap adds the values of a map of structs to vars. The map key
+// is inserted into the variable names, e.g. KEY_<name>_FIELD.
+func (d *dumper) dumpStructMap(rv reflect.Value, key string, vars map[string]string) error {
+	iter := rv.MapRange()
+	for iter.Next() {
+		item := reflect.Indirect(iter.Value())
+		if !item.IsValid() {
+			continue
+		}
+		prefix := key + "_" + iter.Key().String() + "_"
+		if err := d.dumpStruct(item, prefix, vars); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func dumpSlice(rv reflect.Value) (string, error) {
@@ -162,8 +408,56 @@ func dumpSlice(rv reflect.Value) (string, error) {
 	return strings.Join(values, ","), nil
 }
 
+// dumpEnum returns the symbolic name(s) of an enum value or slice of values.
+// Values without a name are formatted by toString.
+func dumpEnum(rv reflect.Value, enum *enumTable) (string, error) {
+	if rv.Kind() == reflect.Slice {
+		values := make([]string, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			s, err := dumpEnum(rv.Index(i), enum)
+			if err != nil {
+				return "", err
+			}
+			values[i] = s
+		}
+		return strings.Join(values, ","), nil
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", nil
+		}
+		rv = rv.Elem()
+	}
+	if s, ok := enum.name(rv); ok {
+		return s, nil
+	}
+	return toString(rv)
+}
+
 func toString(rv reflect.Value) (value string, err error) {
-	if tm, ok := rv.Interface().(encoding.TextMarshaler); ok {
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return "", nil
+	}
+
+	if fun, ok := typeFormatters[rv.Type()]; ok {
+		return fun(rv)
+	}
+	if rv.Kind() == reflect.Ptr {
+		if fun, ok := typeFormatters[rv.Type().Elem()]; ok {
+			return fun(rv.Elem())
+		}
+	}
+
+	// call methods via a pointer, so those with pointer receivers are found,
+	// but don't turn nested structs into a single variable
+	pv := rv
+	if rv.Kind() != reflect.Ptr && !isNested(rv.Type()) {
+		pv = reflect.New(rv.Type())
+		pv.Elem().Set(rv)
+	}
+
+	if tm, ok := pv.Interface().(encoding.TextMarshaler); ok {
 		data, err := tm.MarshalText()
 		if err != nil {
 			return "", err
@@ -171,7 +465,7 @@ func toString(rv reflect.Value) (value string, err error) {
 		return string(data), nil
 	}
 
-	if s, ok := rv.Interface().(fmt.Stringer); ok {
+	if s, ok := pv.Interface().(fmt.Stringer); ok {
 		return s.String(), nil
 	}
 
@@ -192,6 +486,24 @@ func toString(rv reflect.Value) (value string, err error) {
 		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), nil
 	case reflect.Float64:
 		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	case reflect.Complex64:
+		return formatComplex(rv.Complex(), 64), nil
+	case reflect.Complex128:
+		return formatComplex(rv.Complex(), 128), nil
+	}
+
+	// types with a parser registered by RegisterParser are leaves
+	if hasTypeParser(rv.Type()) || hasTypeParser(reflect.PtrTo(rv.Type())) {
+		return fmt.Sprint(rv.Interface()), nil
 	}
 	return "", errUnknownType
 }
+
+// format a complex number in the same form as strconv.FormatComplex.
+func formatComplex(c complex128, bitSize int) string {
+	im := strconv.FormatFloat(imag(c), 'g', -1, bitSize/2)
+	if im[0] != '+' && im[0] != '-' {
+		im = "+" + im
+	}
+	return "(" + strconv.FormatFloat(real(c), 'g', -1, bitSize/2) + im + "i)"
+}
@@ -4,11 +4,16 @@
 package env
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"math/big"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
+	"text/template"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -242,6 +247,210 @@ func TestDump(t *testing.T) {
 	assert.Equal(t, x, m, "unexpected result")
 }
 
+func TestDump_valueTypes(t *testing.T) {
+	var (
+		u1, _ = url.Parse("https://www.example.com/path?q=1")
+		u2, _ = url.Parse("ftp://example.org")
+	)
+
+	type target struct {
+		URL      url.URL
+		URLS     []url.URL
+		URLSP    []*url.URL
+		Pattern  *regexp.Regexp
+		Body     *template.Template
+		NoBody   *template.Template
+		Patterns []*regexp.Regexp
+	}
+
+	v := target{
+		URL:      *u1,
+		URLS:     []url.URL{*u1, *u2},
+		URLSP:    []*url.URL{u1, nil},
+		Pattern:  regexp.MustCompile(`^\w+$`),
+		Body:     template.Must(template.New("").Parse("Hello, {{.Name}}!")),
+		Patterns: []*regexp.Regexp{regexp.MustCompile("a+"), regexp.MustCompile("b+")},
+	}
+	x := map[string]string{
+		"URL":      "https://www.example.com/path?q=1",
+		"URLS":     "https://www.example.com/path?q=1,ftp://example.org",
+		"URLSP":    "https://www.example.com/path?q=1,",
+		"PATTERN":  `^\w+$`,
+		"BODY":     "Hello, {{.Name}}!",
+		"NO_BODY":  "",
+		"PATTERNS": "a+,b+",
+	}
+
+	m, err := Dump(v)
+	require.NoError(t, err, "dump failed")
+	assert.Equal(t, x, m, "unexpected result")
+
+	// round-trip
+	var v2 target
+	require.NoError(t, Bind(&v2, MapEnv(m)), "bind failed")
+	assert.Equal(t, v.URL, v2.URL, "unexpected URL")
+	assert.Equal(t, v.URLS, v2.URLS, "unexpected URLS")
+	assert.Equal(t, v.Pattern.String(), v2.Pattern.String(), "unexpected Pattern")
+	assert.Equal(t, v.Body.Tree.Root.String(), v2.Body.Tree.Root.String(), "unexpected Body")
+}
+
+func TestDump_templateRoundTrip(t *testing.T) {
+	type target struct {
+		Body *template.Template
+	}
+
+	tests := []struct {
+		src, x string
+	}{
+		{"Hello, {{.}}!", "Hello, Bob!"},
+		{`{{define "x"}}X{{end}}hi {{template "x"}}`, "hi X"},
+		{`{{define "b"}}B{{.}}{{end}}{{define "a"}}A{{template "b" .}}{{end}}{{template "a" .}}!`, "ABBob!"},
+		{`{{block "name" .}}{{.}}{{end}}?`, "Bob?"},
+	}
+
+	for _, td := range tests {
+		td := td
+		t.Run(td.src, func(t *testing.T) {
+			var v target
+			require.NoError(t, Bind(&v, MapEnv{"BODY": td.src}), "bind failed")
+			m, err := Dump(v)
+			require.NoError(t, err, "dump failed")
+
+			var v2 target
+			require.NoError(t, Bind(&v2, MapEnv(m)), "bind dumped value failed")
+			var buf bytes.Buffer
+			require.NoError(t, v2.Body.Execute(&buf, "Bob"), "execute failed")
+			assert.Equal(t, td.x, buf.String(), "unexpected output")
+		})
+	}
+}
+
+func TestDump_bigComplex(t *testing.T) {
+	type target struct {
+		Int     *big.Int
+		Float   *big.Float
+		Rat     *big.Rat
+		Rats    []*big.Rat
+		Complex complex128
+		Small   complex64
+	}
+
+	f, _ := parseBigFloat("3.14159265358979323846264338327950288")
+	v := target{
+		Int:     new(big.Int).Lsh(big.NewInt(1), 100),
+		Float:   f,
+		Rat:     big.NewRat(7, 1),
+		Rats:    []*big.Rat{big.NewRat(1, 3), big.NewRat(-5, 4)},
+		Complex: complex(0.1, -1e30),
+		Small:   complex64(complex(1.1, 0)),
+	}
+	x := map[string]string{
+		"INT":     "1267650600228229401496703205376",
+		"FLOAT":   "3.14159265358979323846264338327950288",
+		"RAT":     "7",
+		"RATS":    "1/3,-5/4",
+		"COMPLEX": "(0.1-1e+30i)",
+		"SMALL":   "(1.1+0i)",
+	}
+
+	m, err := Dump(v)
+	require.NoError(t, err, "dump failed")
+	assert.Equal(t, x, m, "unexpected result")
+
+	// round-trip
+	var v2 target
+	require.NoError(t, Bind(&v2, MapEnv(m)), "bind failed")
+	assert.Equal(t, 0, v.Int.Cmp(v2.Int), "unexpected Int")
+	assert.Equal(t, 0, v.Float.Cmp(v2.Float), "unexpected Float")
+	assert.Equal(t, v.Rat, v2.Rat, "unexpected Rat")
+	assert.Equal(t, v.Rats, v2.Rats, "unexpected Rats")
+	assert.Equal(t, v.Complex, v2.Complex, "unexpected Complex")
+	assert.Equal(t, v.Small, v2.Small, "unexpected Small")
+}
+
+func TestDump_structSlice(t *testing.T) {
+	type target struct {
+		Upstreams []Upstream
+		Mirrors   []*Upstream
+		Empty     []Upstream
+	}
+
+	v := target{
+		Upstreams: []Upstream{
+			{Host: "one.example.com", Port: 80, Backoff: &Nested{NestedNum: 2}},
+			{Host: "two.example.com", Tags: []string{"a", "b"}},
+		},
+		Mirrors: []*Upstream{{Host: "mirror.example.com"}, nil},
+	}
+
+	x := map[string]string{
+		"UPSTREAMS_COUNT":        "2",
+		"UPSTREAMS_0_HOST":       "one.example.com",
+		"UPSTREAMS_0_PORT":       "80",
+		"UPSTREAMS_0_NESTED_NUM": "2",
+		"UPSTREAMS_1_HOST":       "two.example.com",
+		"UPSTREAMS_1_TAGS":       "a,b",
+		"MIRRORS_COUNT":          "2",
+		"MIRRORS_0_HOST":         "mirror.example.com",
+	}
+
+	m, err := Dump(v, IgnoreZeroValues)
+	require.NoError(t, err, "dump failed")
+	assert.Equal(t, x, m, "unexpected result")
+
+	m, err = Dump(v)
+	require.NoError(t, err, "dump failed")
+	assert.Equal(t, "0", m["EMPTY_COUNT"], "unexpected EMPTY_COUNT")
+	assert.Equal(t, "", m["UPSTREAMS_1_BACKOFF"], "unexpected UPSTREAMS_1_BACKOFF")
+
+	// round-trip
+	var v2 target
+	require.NoError(t, Bind(&v2, MapEnv(m)), "bind failed")
+	assert.Equal(t, v.Upstreams[0].Host, v2.Upstreams[0].Host, "unexpected Upstreams")
+	assert.Equal(t, v.Upstreams[1].Tags, v2.Upstreams[1].Tags, "unexpected Upstreams")
+	require.Len(t, v2.Mirrors, 2, "unexpected Mirrors")
+	assert.Equal(t, "mirror.example.com", v2.Mirrors[0].Host, "unexpected Mirrors")
+	assert.Equal(t, &Upstream{}, v2.Mirrors[1], "unexpected Mirrors")
+	assert.Equal(t, []Upstream{}, v2.Empty, "unexpected Empty")
+}
+
+func TestDump_structMap(t *testing.T) {
+	type target struct {
+		DB     map[string]DBConfig
+		Caches map[string]*Upstream
+	}
+
+	v := target{
+		DB: map[string]DBConfig{
+			"PRIMARY":   {Host: "db1.example.com", Port: 5432},
+			"READ_ONLY": {Host: "db2.example.com", Replicas: []Upstream{{Host: "db3.example.com"}}},
+		},
+		Caches: map[string]*Upstream{
+			"redis": {Host: "cache.example.com"},
+			"nil":   nil,
+		},
+	}
+
+	x := map[string]string{
+		"DB_PRIMARY_HOST":              "db1.example.com",
+		"DB_PRIMARY_PORT":              "5432",
+		"DB_READ_ONLY_HOST":            "db2.example.com",
+		"DB_READ_ONLY_REPLICAS_COUNT":  "1",
+		"DB_READ_ONLY_REPLICAS_0_HOST": "db3.example.com",
+		"CACHES_redis_HOST":            "cache.example.com",
+	}
+
+	m, err := Dump(v, IgnoreZeroValues)
+	require.NoError(t, err, "dump failed")
+	assert.Equal(t, x, m, "unexpected result")
+
+	// round-trip
+	var v2 target
+	require.NoError(t, Bind(&v2, MapEnv(m)), "bind failed")
+	assert.Equal(t, v.DB, v2.DB, "unexpected DB")
+	assert.Equal(t, map[string]*Upstream{"redis": {Host: "cache.example.com"}}, v2.Caches, "unexpected Caches")
+}
+
 func TestExport(t *testing.T) {
 	x, v := dumpTestValues()
 
@@ -358,3 +567,140 @@ func TestExport_invalidTarget(t *testing.T) {
 		assert.EqualError(t, err, "not a struct", "dump accepted invalid target")
 	}
 }
+
+func TestExportTo(t *testing.T) {
+	x, v := dumpTestValues()
+
+	e := MapEnv{"OTHER": "value"}
+	require.NoError(t, ExportTo(e, v), "export failed")
+	for k, v := range x {
+		assert.Equalf(t, v, e[k], "unexpected %q", k)
+	}
+	assert.Equal(t, len(x)+1, len(e), "unexpected vars")
+
+	require.NoError(t, UnexportFrom(e, v), "unexport failed")
+	assert.Equal(t, MapEnv{"OTHER": "value"}, e, "unexpected vars")
+
+	err := ExportTo(e, "string")
+	assert.EqualError(t, err, "not a struct", "export accepted invalid target")
+}
+
+func TestUnexport(t *testing.T) {
+	defer os.Clearenv()
+	require.NoError(t, os.Setenv("OTHER", "value"))
+
+	v := struct {
+		Host string
+		Port int
+	}{Host: "localhost"}
+	require.NoError(t, Export(v, IgnoreZeroValues), "export failed")
+	require.NoError(t, os.Setenv("PORT", "8080"))
+	assert.Equal(t, "localhost", os.Getenv("HOST"), "unexpected HOST")
+
+	// zero values are also removed
+	require.NoError(t, Unexport(v, IgnoreZeroValues), "unexport failed")
+	_, ok := os.LookupEnv("HOST")
+	assert.False(t, ok, "HOST set")
+	_, ok = os.LookupEnv("PORT")
+	assert.False(t, ok, "PORT set")
+	assert.Equal(t, "value", os.Getenv("OTHER"), "unexpected OTHER")
+}
+
+// Export a struct to a MapEnv, e.g. the environment of a child process.
+func ExampleExportTo() {
+	v := struct {
+		Host string
+		Port int
+	}{"localhost", 8080}
+
+	e := MapEnv{}
+	if err := ExportTo(e, v); err != nil {
+		panic(err)
+	}
+	fmt.Println(e)
+
+	// Output:
+	// map[HOST:localhost PORT:8080]
+}
+
+// Setter that fails to set variable "fail".
+type failingEnv struct {
+	MapEnv
+	fail string
+}
+
+func (e failingEnv) Set(key, value string) error {
+	if key == e.fail {
+		return fmt.Errorf("cannot set %s", key)
+	}
+	return e.MapEnv.Set(key, value)
+}
+
+func TestExport_rollback(t *testing.T) {
+	v := struct {
+		A, B, C string
+	}{"a", "b", "c"}
+
+	e := failingEnv{MapEnv{"A": "old", "OTHER": "value"}, "C"}
+	err := ExportTo(e, v)
+	assert.EqualError(t, err, "cannot set C", "unexpected error")
+	assert.Equal(t, MapEnv{"A": "old", "OTHER": "value"}, e.MapEnv, "environment changed")
+
+	// os.Setenv rejects names containing "="
+	defer os.Clearenv()
+	require.NoError(t, os.Setenv("A", "old"))
+	err = Export(v, VarNameFunc(func(name string) string {
+		if name == "C" {
+			return "C=D"
+		}
+		return name
+	}))
+	assert.Error(t, err, "accepted invalid name")
+	assert.Equal(t, "old", os.Getenv("A"), "unexpected A")
+	_, ok := os.LookupEnv("B")
+	assert.False(t, ok, "B set")
+}
+
+func TestExportScoped(t *testing.T) {
+	defer os.Clearenv()
+	require.NoError(t, os.Setenv("HOST", "old"))
+
+	v := struct {
+		Host string
+		Port int
+	}{"localhost", 8080}
+	restore, err := ExportScoped(v)
+	require.NoError(t, err, "export failed")
+	assert.Equal(t, "localhost", os.Getenv("HOST"), "unexpected HOST")
+	assert.Equal(t, "8080", os.Getenv("PORT"), "unexpected PORT")
+
+	restore()
+	assert.Equal(t, "old", os.Getenv("HOST"), "unexpected HOST")
+	_, ok := os.LookupEnv("PORT")
+	assert.False(t, ok, "PORT set")
+
+	_, err = ExportScoped("string")
+	assert.EqualError(t, err, "not a struct", "export accepted invalid target")
+}
+
+// Temporarily override variables.
+func ExampleExportScoped() {
+	_ = os.Setenv("LOG_LEVEL", "info")
+
+	v := struct {
+		LogLevel string
+	}{"debug"}
+	restore, err := ExportScoped(v)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(os.Getenv("LOG_LEVEL"))
+	restore()
+	fmt.Println(os.Getenv("LOG_LEVEL"))
+
+	// Output:
+	// debug
+	// info
+
+	os.Clearenv()
+}
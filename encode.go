@@ -0,0 +1,127 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is an output format for Write and Encode.
+type Format int
+
+// Output formats.
+const (
+	FormatDotenv Format = iota // KEY=value, readable by ParseDotenv
+	FormatPOSIX                // export KEY='value'
+	FormatFish                 // set -x KEY 'value'
+	FormatDocker               // KEY=value, for docker run --env-file
+)
+
+// String implements fmt.Stringer.
+func (f Format) String() string {
+	switch f {
+	case FormatDotenv:
+		return "dotenv"
+	case FormatPOSIX:
+		return "posix"
+	case FormatFish:
+		return "fish"
+	case FormatDocker:
+		return "docker"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Write writes variables to w in format f, one per line, sorted by name.
+// Values are quoted as required by the format.
+//
+// Docker env files don't support quoting, so Write returns an error if
+// a value contains a newline. It also returns an error if a name is not
+// a valid variable name.
+func Write(w io.Writer, vars map[string]string, f Format) error {
+	bw := bufio.NewWriter(w)
+	for _, k := range sortedKeys(vars) {
+		if !isName(k) {
+			return fmt.Errorf("invalid variable name %q", k)
+		}
+		line, err := formatLine(k, vars[k], f)
+		if err != nil {
+			return err
+		}
+		if _, err := bw.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Encode dumps struct v (via Dump) and writes the variables to w in
+// format f (via Write). It accepts the same options as Dump.
+func Encode(w io.Writer, v interface{}, f Format, opt ...DumpOption) error {
+	vars, err := Dump(v, opt...)
+	if err != nil {
+		return err
+	}
+	return Write(w, vars, f)
+}
+
+// formatLine returns the line that sets variable key to value in format f.
+func formatLine(key, value string, f Format) (string, error) {
+	switch f {
+	case FormatDotenv:
+		return key + "=" + quoteDotenv(value), nil
+	case FormatPOSIX:
+		return "export " + key + "=" + quotePOSIX(value), nil
+	case FormatFish:
+		return "set -x " + key + " " + quoteFish(value), nil
+	case FormatDocker:
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("%s: docker env files cannot contain newlines", key)
+		}
+		return key + "=" + value, nil
+	}
+	return "", fmt.Errorf("unknown format: %v", f)
+}
+
+// isSafe returns true if s can be written without quotes in any format.
+func isSafe(s string) bool {
+	for _, c := range s {
+		if !isNameRune(c, false) && !strings.ContainsRune("%+,-./:=@", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteDotenv double-quotes s if necessary.
+func quoteDotenv(s string) string {
+	if isSafe(s) {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// quotePOSIX single-quotes s if necessary. To include a single quote,
+// the quoted string is closed, an escaped quote added and the string
+// reopened.
+func quotePOSIX(s string) string {
+	if s != "" && isSafe(s) {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// quoteFish single-quotes s if necessary. Within single quotes, fish
+// only treats \' and \\ as escapes.
+func quoteFish(s string) string {
+	if s != "" && isSafe(s) {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, "'", `\'`)
+	return "'" + r.Replace(s) + "'"
+}
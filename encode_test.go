@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// values that need quoting or escaping
+var trickyValues = map[string]string{
+	"EMPTY":     "",
+	"PLAIN":     "http://example.com/path?x=1",
+	"SPACE":     "hello world",
+	"SINGLE":    "it's",
+	"DOUBLE":    `say "hi"`,
+	"DOLLAR":    "$HOME ${HOME} $",
+	"BACKSLASH": `C:\dir\n`,
+	"NEWLINE":   "line 1\nline 2\r\n",
+	"TAB":       "a\tb",
+	"HASH":      "a #b",
+	"QUOTES":    "`'\"",
+	"UNICODE":   "héllo ✓",
+}
+
+func TestWrite(t *testing.T) {
+	vars := map[string]string{
+		"B":     "it's $5",
+		"A":     "value",
+		"EMPTY": "",
+	}
+
+	tests := []struct {
+		f Format
+		x string
+	}{
+		{FormatDotenv, "A=value\nB=\"it's \\$5\"\nEMPTY=\n"},
+		{FormatPOSIX, "export A=value\nexport B='it'\\''s $5'\nexport EMPTY=''\n"},
+		{FormatFish, "set -x A value\nset -x B 'it\\'s $5'\nset -x EMPTY ''\n"},
+		{FormatDocker, "A=value\nB=it's $5\nEMPTY=\n"},
+	}
+
+	for _, td := range tests {
+		td := td
+		t.Run(td.f.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, vars, td.f), "write failed")
+			assert.Equal(t, td.x, buf.String(), "unexpected result")
+		})
+	}
+}
+
+func TestWrite_invalid(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, Write(&buf, MapEnv{"A B": "x"}, FormatDotenv), "accepted invalid name")
+	assert.Error(t, Write(&buf, MapEnv{"1A": "x"}, FormatPOSIX), "accepted invalid name")
+	assert.EqualError(t, Write(&buf, MapEnv{"A": "x\ny"}, FormatDocker),
+		"A: docker env files cannot contain newlines", "unexpected error")
+	assert.EqualError(t, Write(&buf, MapEnv{"A": "x"}, Format(99)), "unknown format: Format(99)", "unexpected error")
+}
+
+func TestWrite_roundTripDotenv(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, trickyValues, FormatDotenv), "write failed")
+
+	v, err := ParseDotenv(&buf)
+	require.NoError(t, err, "parse failed")
+	assert.Equal(t, MapEnv(trickyValues), v, "unexpected result")
+}
+
+func TestWrite_roundTripPOSIX(t *testing.T) {
+	// other tests clear the environment, including PATH
+	sh := "/bin/sh"
+	if _, err := os.Stat(sh); err != nil {
+		t.Skip("sh not found")
+	}
+
+	for k, s := range trickyValues {
+		k, s := k, s
+		t.Run(k, func(t *testing.T) {
+			var script bytes.Buffer
+			require.NoError(t, Write(&script, MapEnv{k: s}, FormatPOSIX), "write failed")
+			script.WriteString(`printf '%s' "$` + k + `"`)
+
+			cmd := exec.Command(sh, "-c", script.String())
+			cmd.Env = []string{"HOME=/wrong"}
+			out, err := cmd.Output()
+			require.NoError(t, err, "script failed")
+			assert.Equal(t, s, string(out), "unexpected result")
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	v := struct {
+		Name string
+		Port int
+		Tags []string
+	}{"my app", 80, []string{"a", "b"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, v, FormatDotenv), "encode failed")
+	assert.Equal(t, "NAME=\"my app\"\nPORT=80\nTAGS=a,b\n", buf.String(), "unexpected result")
+
+	assert.Equal(t, ErrNotStruct, Encode(&buf, "x", FormatDotenv), "unexpected error")
+}
+
+// Write a struct as a shell script.
+func ExampleEncode() {
+	cfg := struct {
+		Greeting string
+		Count    int
+		Motd     string
+	}{"Hello, world!", 3, "it's $5"}
+
+	if err := Encode(os.Stdout, cfg, FormatPOSIX); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// export COUNT=3
+	// export GREETING='Hello, world!'
+	// export MOTD='it'\''s $5'
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// enumTable maps the symbolic names of a type's values to the values.
+type enumTable struct {
+	typ    reflect.Type
+	names  []string                 // names in the order they were defined
+	values map[string]reflect.Value // lowercase name -> value
+	byVal  map[interface{}]string   // value -> canonical name
+}
+
+// Registered enum tables.
+var (
+	enumsMu sync.RWMutex
+	enums   = map[reflect.Type]*enumTable{}
+)
+
+// RegisterEnum registers symbolic names for the values of a type, so that
+// Bind, Dump and Reader.GetEnum can convert between names and values.
+//
+// table must be a map whose keys are strings and whose values are of the
+// enumerated type, e.g.:
+//
+//	env.RegisterEnum(map[string]LogLevel{
+//		"debug": LevelDebug,
+//		"info":  LevelInfo,
+//		"warn":  LevelWarn,
+//	})
+//
+// Names are matched case-insensitively. If several names map to the same
+// value, Dump uses the first in alphabetical order. RegisterEnum panics if
+// table is not a map of the correct type.
+func RegisterEnum(table interface{}) {
+	rv := reflect.ValueOf(table)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		panic(fmt.Sprintf("env: RegisterEnum requires a map[string]T, not %T", table))
+	}
+
+	var names []string
+	for _, k := range rv.MapKeys() {
+		names = append(names, k.String())
+	}
+	sort.Strings(names)
+
+	t := newEnumTable(rv.Type().Elem())
+	for _, name := range names {
+		t.add(name, rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key())))
+	}
+
+	enumsMu.Lock()
+	enums[t.typ] = t
+	enumsMu.Unlock()
+}
+
+func newEnumTable(typ reflect.Type) *enumTable {
+	return &enumTable{
+		typ:    typ,
+		values: map[string]reflect.Value{},
+		byVal:  map[interface{}]string{},
+	}
+}
+
+// add a name-value pair to the table.
+func (t *enumTable) add(name string, rv reflect.Value) {
+	key := strings.ToLower(name)
+	if _, ok := t.values[key]; ok {
+		return
+	}
+	t.names = append(t.names, name)
+	t.values[key] = rv
+	if _, ok := t.byVal[rv.Interface()]; !ok {
+		t.byVal[rv.Interface()] = name
+	}
+}
+
+// parse returns the value named by s.
+func (t *enumTable) parse(s string) (reflect.Value, error) {
+	if rv, ok := t.values[strings.ToLower(s)]; ok {
+		return rv, nil
+	}
+	name := t.typ.Name()
+	if name == "" {
+		name = t.typ.String()
+	}
+	return reflect.Value{}, fmt.Errorf("invalid %s %q (allowed values: %s)",
+		name, s, strings.Join(t.names, ", "))
+}
+
+// name returns the symbolic name of rv.
+func (t *enumTable) name(rv reflect.Value) (string, bool) {
+	s, ok := t.byVal[rv.Convert(t.typ).Interface()]
+	return s, ok
+}
+
+// parseEnumTag creates an enumTable from an `enum:"..."` tag, which has
+// the form "name:value,name:value,...". Values are parsed according to typ.
+func parseEnumTag(tag string, typ reflect.Type) (*enumTable, error) {
+	t := newEnumTable(typ)
+	for _, pair := range strings.Split(tag, ",") {
+		i := strings.LastIndex(pair, ":")
+		if i < 1 {
+			return nil, fmt.Errorf("invalid enum tag %q", tag)
+		}
+		name, value := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		v, err := parseValue(typ, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enum tag %q: %w", tag, err)
+		}
+		t.add(name, v)
+	}
+	return t, nil
+}
+
+// lookupEnum returns the enumTable registered for typ, or nil.
+func lookupEnum(typ reflect.Type) *enumTable {
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+	return enums[typ]
+}
+
+// fieldEnum returns the enumTable for a struct field, or nil if the field
+// is not an enumerated type. An `enum:"..."` tag takes precedence over
+// a table registered with RegisterEnum. For slice fields, the table
+// applies to the slice's items.
+func fieldEnum(field reflect.StructField) (*enumTable, error) {
+	typ := enumType(field.Type)
+	if tag := field.Tag.Get("enum"); tag != "" {
+		return parseEnumTag(tag, typ)
+	}
+	return lookupEnum(typ), nil
+}
+
+// enumType returns the type whose enum applies to values of type typ,
+// i.e. typ with any pointers and slices removed.
+func enumType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+	}
+	return typ
+}
+
+// GetEnum returns the value for envvar "key" converted to the type of
+// fallback via the names registered with RegisterEnum. If the variable is
+// unset or isn't a valid name, fallback is returned. Names are matched
+// case-insensitively.
+//
+// GetEnum panics if no names have been registered for fallback's type.
+func GetEnum(key string, fallback interface{}) interface{} {
+	return Default().GetEnum(key, fallback)
+}
+
+// GetEnum returns the value for envvar "key" converted to the type of
+// fallback via the names registered with RegisterEnum. If the variable is
+// unset or isn't a valid name, fallback is returned. Names are matched
+// case-insensitively.
+//
+// GetEnum panics if no names have been registered for fallback's type.
+func (r Reader) GetEnum(key string, fallback interface{}) interface{} {
+	v, ok, err := r.LookupEnum(key, fallback)
+	r.errs.add(r.prefix+key, ok, err, false)
+	if !ok || err != nil {
+		return fallback
+	}
+	return v
+}
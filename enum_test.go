@@ -0,0 +1,202 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLevel int
+
+const (
+	levelDebug testLevel = iota
+	levelInfo
+	levelWarn
+)
+
+type testColour string
+
+func init() {
+	RegisterEnum(map[string]testLevel{
+		"debug": levelDebug,
+		"info":  levelInfo,
+		"warn":  levelWarn,
+		"WARN2": levelWarn,
+	})
+}
+
+type EnumTarget struct {
+	Level   testLevel
+	LevelP  *testLevel `env:"LEVEL"`
+	Levels  []testLevel
+	LevelsP []*testLevel `env:"LEVELS"`
+
+	Mode    int         `enum:"off:0, on:1, auto:2"`
+	Colour  testColour  `enum:"red:#f00,green:#0f0"`
+	ColourP *testColour `env:"COLOUR" enum:"red:#f00,green:#0f0"`
+	Modes   []uint8     `enum:"off:0,on:1"`
+}
+
+func TestBind_enum(t *testing.T) {
+	var (
+		info = levelInfo
+		warn = levelWarn
+		red  = testColour("#f00")
+	)
+
+	e := MapEnv{
+		"LEVEL":  "Info",
+		"LEVELS": "warn,INFO,warn2",
+		"MODE":   "AUTO",
+		"COLOUR": "red",
+		"MODES":  "on,off",
+	}
+
+	x := EnumTarget{
+		Level:   levelInfo,
+		LevelP:  &info,
+		Levels:  []testLevel{levelWarn, levelInfo, levelWarn},
+		LevelsP: []*testLevel{&warn, &info, &warn},
+		Mode:    2,
+		Colour:  red,
+		ColourP: &red,
+		Modes:   []uint8{1, 0},
+	}
+
+	var v EnumTarget
+	require.NoError(t, Bind(&v, e), "bind failed")
+	assert.Equal(t, x, v, "unexpected result")
+}
+
+func TestBind_enumInvalid(t *testing.T) {
+	tests := []struct {
+		key, value, err string
+	}{
+		{"LEVEL", "error", `invalid testLevel "error" (allowed values: WARN2, debug, info, warn)`},
+		{"LEVELS", "info,error", `invalid testLevel "error" (allowed values: WARN2, debug, info, warn)`},
+		{"LEVEL", "1", `invalid testLevel "1" (allowed values: WARN2, debug, info, warn)`},
+		{"MODE", "maybe", `invalid int "maybe" (allowed values: off, on, auto)`},
+	}
+
+	for _, td := range tests {
+		td := td
+		t.Run(td.key+"="+td.value, func(t *testing.T) {
+			var v EnumTarget
+			assert.EqualError(t, Bind(&v, MapEnv{td.key: td.value}), td.err, "unexpected error")
+		})
+	}
+
+	bad := struct {
+		Mode int `enum:"off=0"`
+	}{}
+	assert.Error(t, Bind(&bad, MapEnv{"MODE": "off"}), "accepted invalid enum tag")
+	bad2 := struct {
+		Mode int `enum:"off:zero"`
+	}{}
+	assert.Error(t, Bind(&bad2, MapEnv{"MODE": "off"}), "accepted invalid enum tag")
+}
+
+func TestDump_enum(t *testing.T) {
+	var (
+		debug = levelDebug
+		warn  = levelWarn
+		green = testColour("#0f0")
+	)
+	v := EnumTarget{
+		Level:   levelWarn,
+		LevelP:  &warn,
+		Levels:  []testLevel{levelDebug, levelWarn},
+		LevelsP: []*testLevel{&debug, &warn},
+		Mode:    1,
+		Colour:  green,
+		ColourP: &green,
+		Modes:   []uint8{0, 1},
+	}
+
+	// WARN2 is alphabetically before warn
+	x := map[string]string{
+		"LEVEL":  "WARN2",
+		"LEVELS": "debug,WARN2",
+		"MODE":   "on",
+		"COLOUR": "green",
+		"MODES":  "off,on",
+	}
+	m, err := Dump(v)
+	require.NoError(t, err, "dump failed")
+	assert.Equal(t, x, m, "unexpected result")
+
+	// round-trip
+	var v2 EnumTarget
+	require.NoError(t, Bind(&v2, MapEnv(m)), "bind failed")
+	assert.Equal(t, v, v2, "unexpected result")
+
+	// value without a name
+	v = EnumTarget{Levels: []testLevel{levelDebug, testLevel(10)}}
+	m, err = Dump(v, IgnoreZeroValues)
+	require.NoError(t, err, "dump failed")
+	assert.Equal(t, map[string]string{"LEVELS": "debug,10"}, m, "unexpected result")
+}
+
+func TestGetEnum(t *testing.T) {
+	r := New(MapEnv{
+		"LEVEL":   "warn",
+		"INVALID": "nonsense",
+		"EMPTY":   "",
+	})
+
+	assert.Equal(t, levelWarn, r.GetEnum("LEVEL", levelInfo), "unexpected LEVEL")
+	assert.Equal(t, levelInfo, r.GetEnum("INVALID", levelInfo), "unexpected INVALID")
+	assert.Equal(t, levelDebug, r.GetEnum("EMPTY", levelDebug), "unexpected EMPTY")
+	assert.Equal(t, levelInfo, r.GetEnum("UNSET", levelInfo), "unexpected UNSET")
+	assert.Panics(t, func() { r.GetEnum("LEVEL", 0) }, "unregistered type accepted")
+}
+
+func TestRegisterEnum_invalid(t *testing.T) {
+	assert.Panics(t, func() { RegisterEnum([]string{}) }, "accepted slice")
+	assert.Panics(t, func() { RegisterEnum(map[int]int{}) }, "accepted map[int]int")
+}
+
+// Bind named values to a custom type.
+func ExampleRegisterEnum() {
+	type LogLevel int
+
+	const (
+		Debug LogLevel = iota
+		Info
+		Warning
+	)
+
+	RegisterEnum(map[string]LogLevel{
+		"debug":   Debug,
+		"info":    Info,
+		"warning": Warning,
+	})
+
+	_ = os.Setenv("LOG_LEVEL", "Warning")
+
+	c := struct {
+		LogLevel LogLevel
+	}{}
+	if err := Bind(&c); err != nil {
+		panic(err)
+	}
+
+	fmt.Println(c.LogLevel == Warning)
+	fmt.Println(GetEnum("LOG_LEVEL", Info) == Warning)
+
+	_ = os.Setenv("LOG_LEVEL", "silent")
+	fmt.Println(Bind(&c))
+
+	// Output:
+	// true
+	// true
+	// invalid LogLevel "silent" (allowed values: debug, info, warning)
+
+	os.Clearenv()
+}
@@ -5,25 +5,89 @@ package env // import "go.deanishe.net/env"
 
 import (
 	"fmt"
+	"math/big"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 var (
 	// System retrieves values from the system environment.
 	System Env = systemEnv{}
-	// Default Reader, which reads from the system environment.
-	system = Reader{System}
+
+	// Env read by package-level functions.
+	defaultMu  sync.RWMutex
+	defaultEnv = System
 )
 
+// Default returns the Reader used by the package-level functions, e.g.
+// Get and GetInt. It reads from the Env passed to SetDefault, which is
+// System unless changed.
+func Default() Reader {
+	return Reader{env: defaultEnvironment()}
+}
+
+// SetDefault sets the Env read by the package-level functions and by
+// Bind and Usage if no Env is specified. Passing nil restores System.
+// It returns the previous Env, so it can be restored, e.g. in tests:
+//
+//	defer env.SetDefault(env.SetDefault(env.MapEnv{"HOST": "localhost"}))
+//
+// Only the package-level functions are affected. Readers created with New
+// keep reading from their own Env.
+func SetDefault(env Env) Env {
+	if env == nil {
+		env = System
+	}
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	prev := defaultEnv
+	defaultEnv = env
+	return prev
+}
+
+// defaultEnvironment returns the Env set with SetDefault.
+func defaultEnvironment() Env {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultEnv
+}
+
 // systemEnv reads values from the real environment
 type systemEnv struct{}
 
+// Set implements Setter.
+func (env systemEnv) Set(key, value string) error {
+	return os.Setenv(key, value)
+}
+
+// Unset implements Setter.
+func (env systemEnv) Unset(key string) error {
+	return os.Unsetenv(key)
+}
+
 func (env systemEnv) Lookup(key string) (string, bool) {
 	return os.LookupEnv(key)
 }
 
+// Name implements Namer.
+func (env systemEnv) Name() string { return "environment" }
+
+// Keys implements Lister.
+func (env systemEnv) Keys() []string {
+	var keys []string
+	for _, s := range os.Environ() {
+		if i := strings.Index(s, "="); i > 0 {
+			keys = append(keys, s[:i])
+		}
+	}
+	return keys
+}
+
 // Env is the data source for bindings and lookup. It is an optional
 // parameter to Bind(). By specifying a custom Env, it's possible
 // to populate a struct from an alternative source.
@@ -49,14 +113,327 @@ func (env MapEnv) Lookup(key string) (string, bool) {
 	return s, ok
 }
 
+// Set implements Setter.
+func (env MapEnv) Set(key, value string) error {
+	env[key] = value
+	return nil
+}
+
+// Unset implements Setter.
+func (env MapEnv) Unset(key string) error {
+	delete(env, key)
+	return nil
+}
+
+// Keys implements Lister.
+func (env MapEnv) Keys() []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Lister is an optional interface for Envs that can enumerate their
+// variables. System and MapEnv implement Lister.
+//
+// Some features, such as binding maps of structs, require an Env that
+// implements Lister, and are skipped for Envs that don't.
+type Lister interface {
+	// Keys returns the names of all variables in the Env, in no
+	// particular order.
+	Keys() []string
+}
+
+// Setter is an Env whose variables can be changed. System and MapEnv
+// implement Setter.
+//
+// ExportTo and UnexportFrom write a struct's variables to a Setter.
+type Setter interface {
+	Env
+	// Set sets the value of a variable.
+	Set(key, value string) error
+	// Unset removes a variable.
+	Unset(key string) error
+}
+
+// Keys returns the sorted names of the variables in Env that start
+// with prefix. Pass an empty prefix to retrieve all variables.
+//
+// If env does not implement Lister, Keys returns nil.
+func Keys(env Env, prefix string) []string {
+	l, ok := env.(Lister)
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for _, k := range l.Keys() {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PrefixEnv returns an Env that reads the variables of env whose names
+// start with prefix. The prefix is added to keys passed to Lookup,
+// so PrefixEnv(env, "REDIS_").Lookup("HOST") retrieves REDIS_HOST from env.
+// PrefixEnvs may be nested to any depth.
+//
+// If env implements Lister, the returned Env lists the variables that
+// start with prefix, with the prefix removed.
+func PrefixEnv(env Env, prefix string) Env {
+	if pe, ok := env.(prefixEnv); ok {
+		return prefixEnv{pe.env, pe.prefix + prefix}
+	}
+	return prefixEnv{env, prefix}
+}
+
+// prefixEnv implements PrefixEnv.
+type prefixEnv struct {
+	env    Env
+	prefix string
+}
+
+// Lookup implements Env.
+func (pe prefixEnv) Lookup(key string) (string, bool) {
+	return pe.env.Lookup(pe.prefix + key)
+}
+
+// LookupErr implements FallibleEnv.
+func (pe prefixEnv) LookupErr(key string) (string, bool, error) {
+	s, _, ok, err := lookupSource(pe, key)
+	return s, ok, err
+}
+
+// Keys implements Lister.
+func (pe prefixEnv) Keys() []string {
+	keys := Keys(pe.env, pe.prefix)
+	for i, k := range keys {
+		keys[i] = k[len(pe.prefix):]
+	}
+	return keys
+}
+
+// Namer is implemented by Envs that have a human-readable name, which is
+// used to report where a value came from.
+type Namer interface {
+	Name() string
+}
+
+// Named returns an Env that reads from env and is called name. Use it
+// to label the layers of a ChainEnv, e.g.:
+//
+//	Chain(Named("flags", flagEnv), Named(".env", dotenv), System)
+//
+// The returned Env implements Lister if env does.
+func Named(name string, env Env) Env {
+	return namedEnv{env, name}
+}
+
+// namedEnv implements Named.
+type namedEnv struct {
+	env  Env
+	name string
+}
+
+// Lookup implements Env.
+func (ne namedEnv) Lookup(key string) (string, bool) { return ne.env.Lookup(key) }
+
+// LookupErr implements FallibleEnv.
+func (ne namedEnv) LookupErr(key string) (string, bool, error) {
+	s, _, ok, err := lookupSource(ne.env, key)
+	return s, ok, err
+}
+
+// Keys implements Lister.
+func (ne namedEnv) Keys() []string { return Keys(ne.env, "") }
+
+// Name implements Namer.
+func (ne namedEnv) Name() string { return ne.name }
+
+// SourceName returns the name of env. If env implements Namer, its
+// name is returned, otherwise its type.
+func SourceName(env Env) string {
+	if env == nil {
+		return ""
+	}
+	if n, ok := env.(Namer); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", env)
+}
+
+// FallibleEnv is implemented by Envs whose lookups can fail, e.g. because
+// a value can't be decrypted. Bind calls LookupErr instead of Lookup and
+// returns any error. Lookup should treat a failed lookup as an unset
+// variable.
+//
+// ChainEnv and the Envs returned by PrefixEnv and Named implement
+// FallibleEnv by passing on the errors of the Envs they wrap.
+//
+// The Envs returned by DecryptingEnv and ResolvingEnv transform the values
+// of the Env they wrap, and implement FallibleEnv to report values that
+// can't be transformed. Their Lookup methods treat such values as unset.
+// They implement Lister if the wrapped Env does, and have its name (see
+// SourceName).
+type FallibleEnv interface {
+	Env
+	LookupErr(key string) (value string, ok bool, err error)
+}
+
+// lookupSource retrieves key from env and returns the innermost Env that
+// supplied the value, looking inside ChainEnvs and PrefixEnvs. Envs
+// returned by Named are reported as the source of their values.
+func lookupSource(env Env, key string) (value string, source Env, ok bool, err error) {
+	switch e := env.(type) {
+	case ChainEnv:
+		for _, layer := range e {
+			if value, source, ok, err = lookupSource(layer, key); ok || err != nil {
+				return value, source, ok, err
+			}
+		}
+		return "", nil, false, nil
+	case prefixEnv:
+		return lookupSource(e.env, e.prefix+key)
+	case namedEnv:
+		value, _, ok, err = lookupSource(e.env, key)
+		return value, env, ok, err
+	case FallibleEnv:
+		value, ok, err = e.LookupErr(key)
+		return value, env, ok, err
+	}
+	value, ok = env.Lookup(key)
+	return value, env, ok, nil
+}
+
+// ChainEnv is a list of Envs that are searched in order. Lookup returns
+// the value from the first Env in which a variable is set, so earlier
+// Envs take precedence over later ones.
+//
+// ChainEnv implements Lister. Envs in the chain that don't implement
+// Lister are ignored by Keys.
+type ChainEnv []Env
+
+// Chain returns a ChainEnv that searches envs in order.
+//
+// For example, to give command-line options precedence over environment
+// variables:
+//
+//	err := Bind(opts, Chain(flagEnv, System))
+func Chain(envs ...Env) ChainEnv {
+	return ChainEnv(envs)
+}
+
+// Lookup implements Env.
+func (c ChainEnv) Lookup(key string) (string, bool) {
+	s, _, ok := c.LookupSource(key)
+	return s, ok
+}
+
+// LookupSource is like Lookup, but also returns the Env that supplied
+// the value. If the value came from a nested ChainEnv, source is the
+// Env within that chain.
+func (c ChainEnv) LookupSource(key string) (value string, source Env, ok bool) {
+	value, source, ok, err := lookupSource(c, key)
+	if err != nil {
+		return "", nil, false
+	}
+	return value, source, ok
+}
+
+// LookupErr implements FallibleEnv.
+func (c ChainEnv) LookupErr(key string) (string, bool, error) {
+	s, _, ok, err := lookupSource(c, key)
+	return s, ok, err
+}
+
+// Keys implements Lister.
+func (c ChainEnv) Keys() []string {
+	return mergeKeys(c)
+}
+
+// MergeEnv is a list of Envs whose values are combined. Lookup returns
+// the non-empty values of a variable from all Envs in order, joined with
+// commas, so when binding a slice, the items from all Envs are appended.
+//
+// MergeEnv implements Lister. Envs that don't implement Lister are
+// ignored by Keys.
+type MergeEnv []Env
+
+// Merge returns a MergeEnv that combines the values of envs.
+func Merge(envs ...Env) MergeEnv {
+	return MergeEnv(envs)
+}
+
+// Lookup implements Env. The returned boolean is true if the variable
+// is set in any of the Envs.
+func (m MergeEnv) Lookup(key string) (string, bool) {
+	var (
+		values []string
+		found  bool
+	)
+	for _, e := range m {
+		s, ok := e.Lookup(key)
+		if !ok {
+			continue
+		}
+		found = true
+		if s != "" {
+			values = append(values, s)
+		}
+	}
+	return strings.Join(values, ","), found
+}
+
+// Keys implements Lister.
+func (m MergeEnv) Keys() []string {
+	return mergeKeys(m)
+}
+
+// return the unique keys of envs.
+func mergeKeys(envs []Env) []string {
+	var (
+		keys []string
+		seen = map[string]bool{}
+	)
+	for _, e := range envs {
+		for _, k := range Keys(e, "") {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
 // Reader converts values from Env into other types.
 type Reader struct {
-	env Env
+	env    Env
+	prefix string     // prefix added by Sub, for error messages
+	errs   *collector // non-nil if Reader is collecting errors
 }
 
 // New creates a new Reader based on Env.
 func New(env Env) Reader {
-	return Reader{env}
+	return Reader{env: env}
+}
+
+// Sub returns a Reader for the variables of the default Env whose names
+// start with prefix. See Reader.Sub.
+func Sub(prefix string) Reader {
+	return Default().Sub(prefix)
+}
+
+// Sub returns a Reader for the variables whose names start with prefix.
+// The prefix is added to all keys, so r.Sub("REDIS_").Get("HOST")
+// returns the value of REDIS_HOST. The returned Reader can itself be
+// passed to Sub to create nested scopes.
+func (r Reader) Sub(prefix string) Reader {
+	return Reader{env: PrefixEnv(r.env, prefix), prefix: r.prefix + prefix, errs: r.errs}
 }
 
 // Get returns the value for envvar "key".
@@ -65,7 +442,7 @@ func New(env Env) Reader {
 //
 // If a variable is set, but empty, its value is used.
 func Get(key string, fallback ...string) string {
-	return system.Get(key, fallback...)
+	return Default().Get(key, fallback...)
 }
 
 // Get returns the value for envvar "key".
@@ -79,7 +456,11 @@ func (r Reader) Get(key string, fallback ...string) string {
 		fb = fallback[0]
 	}
 
-	s, ok := r.env.Lookup(key)
+	s, _, ok, err := lookupSource(r.env, key)
+	if err != nil {
+		ok, err = false, fmt.Errorf("%s: %w", r.prefix+key, err)
+	}
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
 	if !ok {
 		return fb
 	}
@@ -88,7 +469,7 @@ func (r Reader) Get(key string, fallback ...string) string {
 
 // GetString is a synonym for Get.
 func GetString(key string, fallback ...string) string {
-	return system.GetString(key, fallback...)
+	return Default().GetString(key, fallback...)
 }
 
 // GetString is a synonym for Get.
@@ -104,7 +485,7 @@ func (r Reader) GetString(key string, fallback ...string) string {
 // fails, tries to parse the number with strconv.ParseFloat() and
 // truncate it to an int.
 func GetInt(key string, fallback ...int) int {
-	return system.GetInt(key, fallback...)
+	return Default().GetInt(key, fallback...)
 }
 
 // GetInt returns the value for envvar "key" as an int.
@@ -119,13 +500,9 @@ func (r Reader) GetInt(key string, fallback ...int) int {
 	if len(fallback) > 0 {
 		fb = fallback[0]
 	}
-	s, ok := r.env.Lookup(key)
-	if !ok {
-		return fb
-	}
-
-	i, err := parseInt(s)
-	if err != nil {
+	i, ok, err := r.LookupInt(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
 		return fb
 	}
 	return i
@@ -139,7 +516,7 @@ func (r Reader) GetInt(key string, fallback ...int) int {
 // fails, tries to parse the number with strconv.ParseFloat() and
 // truncate it to a uint.
 func GetUint(key string, fallback ...uint) uint {
-	return system.GetUint(key, fallback...)
+	return Default().GetUint(key, fallback...)
 }
 
 // GetUint returns the value for envvar "key" as an int.
@@ -154,13 +531,9 @@ func (r Reader) GetUint(key string, fallback ...uint) uint {
 	if len(fallback) > 0 {
 		fb = fallback[0]
 	}
-	s, ok := r.env.Lookup(key)
-	if !ok {
-		return fb
-	}
-
-	i, err := parseUint(s)
-	if err != nil {
+	i, ok, err := r.LookupUint(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
 		return fb
 	}
 	return i
@@ -172,7 +545,7 @@ func (r Reader) GetUint(key string, fallback ...uint) uint {
 //
 // Values are parsed with strconv.ParseFloat().
 func GetFloat(key string, fallback ...float64) float64 {
-	return system.GetFloat(key, fallback...)
+	return Default().GetFloat(key, fallback...)
 }
 
 // GetFloat returns the value for envvar "key" as a float.
@@ -185,13 +558,123 @@ func (r Reader) GetFloat(key string, fallback ...float64) float64 {
 	if len(fallback) > 0 {
 		fb = fallback[0]
 	}
-	s, ok := r.env.Lookup(key)
-	if !ok {
+	n, ok, err := r.LookupFloat(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
 		return fb
 	}
+	return n
+}
 
-	n, err := strconv.ParseFloat(s, 64)
-	if err != nil {
+// GetComplex returns the value for envvar "key" as a complex number.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or 0.
+//
+// Values have the form "(1.5+2i)". The parentheses are optional,
+// as is either the real or the imaginary part.
+func GetComplex(key string, fallback ...complex128) complex128 {
+	return Default().GetComplex(key, fallback...)
+}
+
+// GetComplex returns the value for envvar "key" as a complex number.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or 0.
+//
+// Values have the form "(1.5+2i)". The parentheses are optional,
+// as is either the real or the imaginary part.
+func (r Reader) GetComplex(key string, fallback ...complex128) complex128 {
+	var fb complex128
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+	c, ok, err := r.LookupComplex(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
+		return fb
+	}
+	return c
+}
+
+// GetBigInt returns the value for envvar "key" as a *big.Int.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or 0.
+//
+// Values are parsed with big.Int.SetString() and may have a base
+// prefix, e.g. "0x".
+func GetBigInt(key string, fallback ...*big.Int) *big.Int {
+	return Default().GetBigInt(key, fallback...)
+}
+
+// GetBigInt returns the value for envvar "key" as a *big.Int.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or 0.
+//
+// Values are parsed with big.Int.SetString() and may have a base
+// prefix, e.g. "0x".
+func (r Reader) GetBigInt(key string, fallback ...*big.Int) *big.Int {
+	fb := new(big.Int)
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+	n, ok, err := r.LookupBigInt(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
+		return fb
+	}
+	return n
+}
+
+// GetBigFloat returns the value for envvar "key" as a *big.Float.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or 0.
+//
+// The precision of the returned value is sufficient to represent
+// every digit of the variable's value.
+func GetBigFloat(key string, fallback ...*big.Float) *big.Float {
+	return Default().GetBigFloat(key, fallback...)
+}
+
+// GetBigFloat returns the value for envvar "key" as a *big.Float.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or 0.
+//
+// The precision of the returned value is sufficient to represent
+// every digit of the variable's value.
+func (r Reader) GetBigFloat(key string, fallback ...*big.Float) *big.Float {
+	fb := new(big.Float)
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+	f, ok, err := r.LookupBigFloat(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
+		return fb
+	}
+	return f
+}
+
+// GetBigRat returns the value for envvar "key" as a *big.Rat.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or 0.
+//
+// Values may be fractions ("3/4") or decimals ("0.75").
+func GetBigRat(key string, fallback ...*big.Rat) *big.Rat {
+	return Default().GetBigRat(key, fallback...)
+}
+
+// GetBigRat returns the value for envvar "key" as a *big.Rat.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or 0.
+//
+// Values may be fractions ("3/4") or decimals ("0.75").
+func (r Reader) GetBigRat(key string, fallback ...*big.Rat) *big.Rat {
+	fb := new(big.Rat)
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+	n, ok, err := r.LookupBigRat(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
 		return fb
 	}
 	return n
@@ -203,7 +686,7 @@ func (r Reader) GetFloat(key string, fallback ...float64) float64 {
 //
 // Values are parsed with time.ParseDuration().
 func GetDuration(key string, fallback ...time.Duration) time.Duration {
-	return system.GetDuration(key, fallback...)
+	return Default().GetDuration(key, fallback...)
 }
 
 // GetDuration returns the value for envvar "key" as a time.Duration.
@@ -216,13 +699,9 @@ func (r Reader) GetDuration(key string, fallback ...time.Duration) time.Duration
 	if len(fallback) > 0 {
 		fb = fallback[0]
 	}
-	s, ok := r.env.Lookup(key)
-	if !ok {
-		return fb
-	}
-
-	d, err := time.ParseDuration(s)
-	if err != nil {
+	d, ok, err := r.LookupDuration(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
 		return fb
 	}
 	return d
@@ -234,7 +713,7 @@ func (r Reader) GetDuration(key string, fallback ...time.Duration) time.Duration
 //
 // Values are parsed with strconv.ParseBool().
 func GetBool(key string, fallback ...bool) bool {
-	return system.GetBool(key, fallback...)
+	return Default().GetBool(key, fallback...)
 }
 
 // GetBool returns the value for envvar "key" as a boolean.
@@ -247,16 +726,180 @@ func (r Reader) GetBool(key string, fallback ...bool) bool {
 	if len(fallback) > 0 {
 		fb = fallback[0]
 	}
-	s, ok := r.env.Lookup(key)
-	if !ok {
+	b, ok, err := r.LookupBool(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
 		return fb
 	}
+	return b
+}
 
-	b, err := strconv.ParseBool(s)
-	if err != nil {
+// GetStrings returns the value for envvar "key" as a slice of strings.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or nil.
+//
+// Values are split on commas.
+func GetStrings(key string, fallback ...[]string) []string {
+	return Default().GetStrings(key, fallback...)
+}
+
+// GetStrings returns the value for envvar "key" as a slice of strings.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or nil.
+//
+// Values are split on commas.
+func (r Reader) GetStrings(key string, fallback ...[]string) []string {
+	var fb []string
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+	v, ok, err := r.LookupStrings(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
 		return fb
 	}
-	return b
+	return v
+}
+
+// GetInts returns the value for envvar "key" as a slice of ints.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or nil.
+//
+// Values are split on commas, and each item is parsed with
+// strconv.ParseInt().
+func GetInts(key string, fallback ...[]int) []int {
+	return Default().GetInts(key, fallback...)
+}
+
+// GetInts returns the value for envvar "key" as a slice of ints.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or nil.
+//
+// Values are split on commas, and each item is parsed with
+// strconv.ParseInt().
+func (r Reader) GetInts(key string, fallback ...[]int) []int {
+	var fb []int
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+	v, ok, err := r.LookupInts(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
+		return fb
+	}
+	return v
+}
+
+// GetDurations returns the value for envvar "key" as a slice of time.Durations.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or nil.
+//
+// Values are split on commas, and each item is parsed with
+// time.ParseDuration().
+func GetDurations(key string, fallback ...[]time.Duration) []time.Duration {
+	return Default().GetDurations(key, fallback...)
+}
+
+// GetDurations returns the value for envvar "key" as a slice of time.Durations.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or nil.
+//
+// Values are split on commas, and each item is parsed with
+// time.ParseDuration().
+func (r Reader) GetDurations(key string, fallback ...[]time.Duration) []time.Duration {
+	var fb []time.Duration
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+	v, ok, err := r.LookupDurations(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
+		return fb
+	}
+	return v
+}
+
+// GetStringMap returns the value for envvar "key" as a map of strings.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or nil.
+//
+// Values have the form "key=value,key2=value2", i.e. they are split
+// on commas, and each item is split at the first "=".
+func GetStringMap(key string, fallback ...map[string]string) map[string]string {
+	return Default().GetStringMap(key, fallback...)
+}
+
+// GetStringMap returns the value for envvar "key" as a map of strings.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or nil.
+//
+// Values have the form "key=value,key2=value2", i.e. they are split
+// on commas, and each item is split at the first "=".
+func (r Reader) GetStringMap(key string, fallback ...map[string]string) map[string]string {
+	var fb map[string]string
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+	v, ok, err := r.LookupStringMap(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
+		return fb
+	}
+	return v
+}
+
+// GetURL returns the value for envvar "key" as a *url.URL.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or nil.
+//
+// Values are parsed with url.Parse().
+func GetURL(key string, fallback ...*url.URL) *url.URL {
+	return Default().GetURL(key, fallback...)
+}
+
+// GetURL returns the value for envvar "key" as a *url.URL.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or nil.
+//
+// Values are parsed with url.Parse().
+func (r Reader) GetURL(key string, fallback ...*url.URL) *url.URL {
+	var fb *url.URL
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+	v, ok, err := r.LookupURL(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
+		return fb
+	}
+	return v
+}
+
+// GetInt64 returns the value for envvar "key" as an int64.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or 0.
+//
+// Values are parsed with strconv.ParseInt().
+func GetInt64(key string, fallback ...int64) int64 {
+	return Default().GetInt64(key, fallback...)
+}
+
+// GetInt64 returns the value for envvar "key" as an int64.
+// It accepts one optional "fallback" argument. If no
+// envvar is set, returns fallback or 0.
+//
+// Values are parsed with strconv.ParseInt().
+func (r Reader) GetInt64(key string, fallback ...int64) int64 {
+	var fb int64
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+	v, ok, err := r.LookupInt64(key)
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
+		return fb
+	}
+	return v
 }
 
 // parse an int, falling back to parsing it as a float
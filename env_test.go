@@ -5,11 +5,16 @@ package env
 
 import (
 	"fmt"
+	"math/big"
+	"net/url"
 	"os"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGet(t *testing.T) {
@@ -52,6 +57,179 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestKeys(t *testing.T) {
+	env := MapEnv{
+		"APP_NAME":     "test",
+		"APP_DEBUG":    "",
+		"APPLICATION":  "other",
+		"HOME":         "/home/bob",
+		"app_lower":    "lower",
+		"APP_DB_HOST":  "localhost",
+		"XAPP_DB_HOST": "other",
+	}
+
+	assert.Equal(t, []string{"APP_DB_HOST", "APP_DEBUG", "APP_NAME"}, Keys(env, "APP_"), "unexpected keys")
+	assert.Equal(t, []string{"APPLICATION", "APP_DB_HOST", "APP_DEBUG", "APP_NAME"}, Keys(env, "APP"), "unexpected keys")
+	assert.Equal(t, 7, len(Keys(env, "")), "unexpected keys")
+	assert.Nil(t, Keys(env, "NOPE_"), "unexpected keys")
+
+	// System
+	_ = os.Setenv("TEST_KEYS_ONE", "1")
+	_ = os.Setenv("TEST_KEYS_TWO", "")
+	assert.Equal(t, []string{"TEST_KEYS_ONE", "TEST_KEYS_TWO"}, Keys(System, "TEST_KEYS_"), "unexpected keys")
+	os.Clearenv()
+
+	// not a Lister
+	assert.Nil(t, Keys(funcEnv(env.Lookup), ""), "unexpected keys")
+}
+
+func TestPrefixEnv(t *testing.T) {
+	env := MapEnv{
+		"HOST":                "localhost",
+		"REDIS_HOST":          "redis.example.com",
+		"REDIS_PORT":          "6379",
+		"REDIS_CACHE_TTL":     "5m",
+		"REDIS_CACHE_ENABLED": "",
+	}
+
+	redis := PrefixEnv(env, "REDIS_")
+	s, ok := redis.Lookup("HOST")
+	assert.True(t, ok, "REDIS_HOST not found")
+	assert.Equal(t, "redis.example.com", s, "unexpected HOST")
+	_, ok = redis.Lookup("REDIS_HOST")
+	assert.False(t, ok, "REDIS_REDIS_HOST found")
+	assert.Equal(t, []string{"CACHE_ENABLED", "CACHE_TTL", "HOST", "PORT"}, Keys(redis, ""), "unexpected keys")
+
+	cache := PrefixEnv(redis, "CACHE_")
+	s, ok = cache.Lookup("TTL")
+	assert.True(t, ok, "REDIS_CACHE_TTL not found")
+	assert.Equal(t, "5m", s, "unexpected TTL")
+	assert.Equal(t, []string{"ENABLED", "TTL"}, Keys(cache, ""), "unexpected keys")
+
+	// Reader
+	r := New(env).Sub("REDIS_")
+	assert.Equal(t, "redis.example.com", r.Get("HOST"), "unexpected HOST")
+	assert.Equal(t, 6379, r.GetInt("PORT"), "unexpected PORT")
+	assert.Equal(t, time.Minute*5, r.Sub("CACHE_").GetDuration("TTL"), "unexpected TTL")
+	assert.Equal(t, "", r.Sub("CACHE_").Get("HOST"), "unexpected HOST")
+
+	// Bind
+	c := struct {
+		Host string
+		Port int
+	}{}
+	require.NoError(t, Bind(&c, redis), "bind failed")
+	assert.Equal(t, "redis.example.com", c.Host, "unexpected Host")
+	assert.Equal(t, 6379, c.Port, "unexpected Port")
+
+	// Env isn't a Lister
+	assert.Nil(t, Keys(PrefixEnv(funcEnv(env.Lookup), "REDIS_"), ""), "unexpected keys")
+}
+
+func TestChain(t *testing.T) {
+	var (
+		flags = MapEnv{"USERNAME": "dave", "DEBUG": "true"}
+		env   = MapEnv{"USERNAME": "bob", "HOST": "localhost", "EMPTY": ""}
+		defs  = MapEnv{"HOST": "example.com", "PORT": "80", "EMPTY": "default"}
+		c     = Chain(flags, env, defs)
+	)
+
+	data := []struct {
+		key   string
+		value string
+		ok    bool
+		src   Env
+	}{
+		{"USERNAME", "dave", true, flags},
+		{"DEBUG", "true", true, flags},
+		{"HOST", "localhost", true, env},
+		{"PORT", "80", true, defs},
+		{"EMPTY", "", true, env},
+		{"MISSING", "", false, nil},
+	}
+
+	for _, td := range data {
+		s, ok := c.Lookup(td.key)
+		assert.Equal(t, td.ok, ok, "unexpected ok")
+		assert.Equal(t, td.value, s, "unexpected value")
+
+		s, src, ok := c.LookupSource(td.key)
+		assert.Equal(t, td.ok, ok, "unexpected ok")
+		assert.Equal(t, td.value, s, "unexpected value")
+		assert.Equal(t, td.src, src, "unexpected source")
+	}
+
+	assert.Equal(t, []string{"DEBUG", "EMPTY", "HOST", "PORT", "USERNAME"}, Keys(c, ""), "unexpected keys")
+	assert.Equal(t, []string{"USERNAME"}, Keys(Chain(funcEnv(env.Lookup), flags), "U"), "unexpected keys")
+
+	// Bind
+	v := struct {
+		Username string
+		Host     string
+		Port     int
+		Debug    bool
+	}{}
+	require.NoError(t, Bind(&v, c), "bind failed")
+	assert.Equal(t, "dave", v.Username, "unexpected Username")
+	assert.Equal(t, "localhost", v.Host, "unexpected Host")
+	assert.Equal(t, 80, v.Port, "unexpected Port")
+	assert.True(t, v.Debug, "unexpected Debug")
+}
+
+func TestMerge(t *testing.T) {
+	var (
+		e1 = MapEnv{"TAGS": "a,b", "HOST": "localhost", "EMPTY": ""}
+		e2 = MapEnv{"TAGS": "c", "PORT": "80"}
+		e3 = MapEnv{"TAGS": "", "PORT": "8080"}
+		m  = Merge(e1, e2, e3)
+	)
+
+	data := []struct {
+		key   string
+		value string
+		ok    bool
+	}{
+		{"TAGS", "a,b,c", true},
+		{"HOST", "localhost", true},
+		{"PORT", "80,8080", true},
+		{"EMPTY", "", true},
+		{"MISSING", "", false},
+	}
+
+	for _, td := range data {
+		s, ok := m.Lookup(td.key)
+		assert.Equal(t, td.ok, ok, "unexpected ok")
+		assert.Equal(t, td.value, s, "unexpected value")
+	}
+
+	assert.Equal(t, []string{"EMPTY", "HOST", "PORT", "TAGS"}, Keys(m, ""), "unexpected keys")
+
+	v := struct {
+		Tags []string
+	}{}
+	require.NoError(t, Bind(&v, m), "bind failed")
+	assert.Equal(t, []string{"a", "b", "c"}, v.Tags, "unexpected Tags")
+}
+
+// Reader.Sub scopes a Reader to variables with a given prefix.
+func ExampleReader_Sub() {
+	r := New(MapEnv{
+		"REDIS_HOST":      "redis.example.com",
+		"REDIS_PORT":      "6379",
+		"REDIS_CACHE_TTL": "5m",
+	})
+
+	redis := r.Sub("REDIS_")
+	fmt.Println(redis.Get("HOST"))
+	fmt.Println(redis.GetInt("PORT"))
+	fmt.Println(redis.Sub("CACHE_").GetDuration("TTL"))
+
+	// Output:
+	// redis.example.com
+	// 6379
+	// 5m0s
+}
+
 // Basic usage of Get. Returns an empty string if variable is unset.
 func ExampleGet() {
 	// Set some test variables
@@ -133,7 +311,7 @@ func TestGetInt(t *testing.T) {
 		{"negative_float", []int{5}, -3},
 	}
 
-	e := &Reader{env}
+	e := &Reader{env: env}
 	// Test GetInt
 	for _, td := range data {
 		v := e.GetInt(td.key, td.fb...)
@@ -198,7 +376,7 @@ func TestGetUint(t *testing.T) {
 		{"float", []uint{5}, 3},
 	}
 
-	e := &Reader{env}
+	e := &Reader{env: env}
 	// Test GetInt
 	for _, td := range data {
 		v := e.GetUint(td.key, td.fb...)
@@ -255,7 +433,7 @@ func TestGetFloat(t *testing.T) {
 		{"word", []float64{5.0}, 5.0},
 	}
 
-	e := &Reader{env}
+	e := &Reader{env: env}
 	// Test GetFloat
 	for _, td := range data {
 		v := e.GetFloat(td.key, td.fb...)
@@ -280,6 +458,77 @@ func ExampleGetFloat() {
 	os.Clearenv()
 }
 
+func TestGetComplex(t *testing.T) {
+	env := MapEnv{
+		"complex": "(1.5-2i)",
+		"real":    "3",
+		"imag":    "2.5i",
+		"empty":   "",
+		"word":    "henry",
+	}
+
+	data := []struct {
+		key string
+		fb  []complex128
+		out complex128
+	}{
+		// valid
+		{"complex", []complex128{}, complex(1.5, -2)},
+		{"real", []complex128{1i}, 3},
+		{"imag", []complex128{}, 2.5i},
+		// empty
+		{"empty", []complex128{}, 0},
+		{"empty", []complex128{1i}, 1i},
+		// unset
+		{"missing", []complex128{}, 0},
+		{"missing", []complex128{1 + 1i}, 1 + 1i},
+		// invalid
+		{"word", []complex128{}, 0},
+		{"word", []complex128{2i}, 2i},
+	}
+
+	e := &Reader{env: env}
+
+	// Test GetComplex
+	for _, td := range data {
+		v := e.GetComplex(td.key, td.fb...)
+		assert.Equal(t, td.out, v, "unexpected result")
+	}
+}
+
+func TestGetBig(t *testing.T) {
+	env := MapEnv{
+		"int":   "123456789012345678901234567890",
+		"hex":   "0xff",
+		"float": "0.1000000000000000000000000000001",
+		"frac":  "3/4",
+		"dec":   "0.75",
+		"empty": "",
+		"word":  "henry",
+	}
+
+	e := &Reader{env: env}
+	fbInt := big.NewInt(42)
+	fbFloat := big.NewFloat(4.2)
+	fbRat := big.NewRat(1, 2)
+
+	assert.Equal(t, "123456789012345678901234567890", e.GetBigInt("int").String(), "unexpected int")
+	assert.Equal(t, "255", e.GetBigInt("hex").String(), "unexpected hex")
+	assert.Equal(t, "0", e.GetBigInt("missing").String(), "unexpected missing")
+	assert.Equal(t, fbInt, e.GetBigInt("missing", fbInt), "unexpected fallback")
+	assert.Equal(t, fbInt, e.GetBigInt("empty", fbInt), "unexpected fallback")
+	assert.Equal(t, fbInt, e.GetBigInt("word", fbInt), "unexpected fallback")
+
+	assert.Equal(t, env["float"], e.GetBigFloat("float").Text('g', -1), "unexpected float")
+	assert.Equal(t, "0", e.GetBigFloat("missing").String(), "unexpected missing")
+	assert.Equal(t, fbFloat, e.GetBigFloat("word", fbFloat), "unexpected fallback")
+
+	assert.Equal(t, big.NewRat(3, 4), e.GetBigRat("frac"), "unexpected frac")
+	assert.Equal(t, big.NewRat(3, 4), e.GetBigRat("dec"), "unexpected dec")
+	assert.Equal(t, "0", e.GetBigRat("missing").RatString(), "unexpected missing")
+	assert.Equal(t, fbRat, e.GetBigRat("word", fbRat), "unexpected fallback")
+}
+
 func TestGetDuration(t *testing.T) {
 	env := MapEnv{
 		"5mins": "5m",
@@ -311,7 +560,7 @@ func TestGetDuration(t *testing.T) {
 		{"word", []time.Duration{time.Second * 5}, time.Second * 5},
 	}
 
-	e := &Reader{env}
+	e := &Reader{env: env}
 
 	// Test GetDuration
 	for _, td := range data {
@@ -382,7 +631,7 @@ func TestGetBool(t *testing.T) {
 		{"word", []bool{true}, true},
 	}
 
-	e := &Reader{env}
+	e := &Reader{env: env}
 
 	// Test GetBool
 	for _, td := range data {
@@ -426,3 +675,117 @@ func ExampleGetBool() {
 
 	os.Clearenv()
 }
+
+func TestGetCollections(t *testing.T) {
+	r := New(MapEnv{
+		"HOSTS":     "a.example.com,b.example.com",
+		"PORTS":     "80,443",
+		"TIMEOUTS":  "1s,1m",
+		"LABELS":    "env=prod,team=ops,expr=a=b",
+		"URL":       "https://example.com/path?q=1",
+		"SIZE":      "8589934592",
+		"BAD_PORTS": "80,https",
+		"BAD_LABEL": "env=prod,team",
+		"BAD_URL":   "http://[::1",
+		"BAD_SIZE":  "1.5",
+	})
+	u, _ := url.Parse("https://example.com/path?q=1")
+	fb, _ := url.Parse("http://localhost")
+
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, r.GetStrings("HOSTS"), "unexpected HOSTS")
+	assert.Equal(t, []string{"x"}, r.GetStrings("UNSET", []string{"x"}), "unexpected fallback")
+	assert.Nil(t, r.GetStrings("UNSET"), "unexpected UNSET")
+
+	assert.Equal(t, []int{80, 443}, r.GetInts("PORTS"), "unexpected PORTS")
+	assert.Equal(t, []int{8080}, r.GetInts("BAD_PORTS", []int{8080}), "unexpected BAD_PORTS")
+
+	assert.Equal(t, []time.Duration{time.Second, time.Minute}, r.GetDurations("TIMEOUTS"), "unexpected TIMEOUTS")
+	assert.Nil(t, r.GetDurations("PORTS"), "unexpected durations")
+
+	assert.Equal(t, map[string]string{"env": "prod", "team": "ops", "expr": "a=b"},
+		r.GetStringMap("LABELS"), "unexpected LABELS")
+	assert.Nil(t, r.GetStringMap("BAD_LABEL"), "unexpected BAD_LABEL")
+
+	assert.Equal(t, u, r.GetURL("URL"), "unexpected URL")
+	assert.Equal(t, fb, r.GetURL("BAD_URL", fb), "unexpected BAD_URL")
+	assert.Nil(t, r.GetURL("UNSET"), "unexpected UNSET")
+
+	assert.Equal(t, int64(8589934592), r.GetInt64("SIZE"), "unexpected SIZE")
+	assert.Equal(t, int64(0), r.GetInt64("BAD_SIZE"), "unexpected BAD_SIZE")
+
+	_, _, err := r.LookupInts("BAD_PORTS")
+	assert.Error(t, err, "accepted invalid int")
+	_, _, err = r.LookupStringMap("BAD_LABEL")
+	assert.EqualError(t, err, `invalid value for BAD_LABEL: invalid map item "team" (expected key=value)`, "unexpected error")
+	_, _, err = r.LookupURL("BAD_URL")
+	assert.Error(t, err, "accepted invalid URL")
+}
+
+// Read lists and maps of values.
+func ExampleGetStrings() {
+	_ = os.Setenv("HOSTS", "a.example.com,b.example.com")
+	_ = os.Setenv("LABELS", "env=prod,team=ops")
+
+	fmt.Println(GetStrings("HOSTS"))
+	fmt.Println(GetStringMap("LABELS"))
+	fmt.Println(GetInts("PORTS", []int{80, 443})) // returns fallback
+
+	// Output:
+	// [a.example.com b.example.com]
+	// map[env:prod team:ops]
+	// [80 443]
+
+	os.Clearenv()
+}
+
+func TestSetDefault(t *testing.T) {
+	require.NoError(t, os.Setenv("HOST", "system"))
+	defer os.Clearenv()
+
+	assert.Equal(t, System, SetDefault(nil), "unexpected default")
+	assert.Equal(t, "system", Get("HOST"), "unexpected HOST")
+
+	e := MapEnv{"HOST": "localhost", "PORT": "8080"}
+	prev := SetDefault(e)
+	assert.Equal(t, System, prev, "unexpected previous Env")
+	assert.Equal(t, "localhost", Get("HOST"), "unexpected HOST")
+	assert.Equal(t, 8080, GetInt("PORT"), "unexpected PORT")
+	assert.Equal(t, "8080", Sub("P").Get("ORT"), "unexpected Sub")
+	assert.Equal(t, e, Default().env, "unexpected Default")
+
+	v := struct{ Host string }{}
+	require.NoError(t, Bind(&v), "bind failed")
+	assert.Equal(t, "localhost", v.Host, "unexpected Host")
+
+	// Readers keep their Env
+	r := New(System)
+	assert.Equal(t, "system", r.Get("HOST"), "unexpected HOST")
+
+	assert.Equal(t, e, SetDefault(prev), "unexpected previous Env")
+	assert.Equal(t, "system", Get("HOST"), "unexpected HOST")
+}
+
+func TestSetDefault_concurrent(t *testing.T) {
+	defer SetDefault(SetDefault(nil))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			SetDefault(MapEnv{"N": strconv.Itoa(i)})
+			_ = GetInt("N")
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Point package-level functions at another Env, e.g. in tests.
+func ExampleSetDefault() {
+	defer SetDefault(SetDefault(MapEnv{"HOST": "localhost"}))
+
+	fmt.Println(Get("HOST"))
+
+	// Output:
+	// localhost
+}
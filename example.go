@@ -0,0 +1,193 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// placeholder for the values of secret fields in Example output.
+const secretPlaceholder = "<secret>"
+
+// Example generates the contents of a .env.example file for struct v,
+// listing every variable that Bind reads into v. It accepts the same
+// options as Dump, but VarNameFunc and MaskSecrets are ignored: variables
+// are named as by Bind, and the values of secrets are always replaced.
+//
+// Each variable is written with its default (current) value, preceded
+// by comments containing the field's `desc:"..."` tag and its type.
+// Fields tagged `env:",required"` are marked as required, and the values
+// of fields tagged `env:",secret"` are replaced with a placeholder:
+//
+//	# Password for the database.
+//	# string, required, secret
+//	DB_PASSWORD=<secret>
+//
+// The variables of the items of slices and maps of structs are shown as
+// commented-out KEY_<n>_FIELD and KEY_<name>_FIELD lines, followed by the
+// variables of the items in v.
+func Example(v interface{}, opt ...DumpOption) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+
+	x := &example{noZero: newDumper(opt...).noZero}
+	x.walk(rv, rv.Type(), "", false, map[reflect.Type]bool{})
+	return x.buf.Bytes(), nil
+}
+
+// example generates the output of Example.
+type example struct {
+	noZero bool
+	buf    bytes.Buffer
+}
+
+// walk writes the variables of struct type typ. rv is a value of typ, or
+// an invalid Value if the struct is nil. If template is true, variables
+// are written as comments, as their names contain <n> or <name>.
+func (x *example) walk(rv reflect.Value, typ reflect.Type, prefix string, template bool, seen map[reflect.Type]bool) {
+	walkVars(rv, typ, prefix, seen, func(key string, field reflect.StructField, rv reflect.Value, kind varKind) {
+		if x.noZero && !template && (!rv.IsValid() || rv.IsZero()) {
+			return
+		}
+
+		switch kind {
+		case sliceVar:
+			elem := structType(field.Type.Elem())
+			var n int
+			if rv.IsValid() {
+				n = rv.Len()
+			}
+			x.add(key+"_COUNT", "", "int, number of items", strconv.Itoa(n), template)
+			x.walk(reflect.Value{}, elem, key+"_<n>_", true, seen)
+			for i := 0; i < n; i++ {
+				if item := reflect.Indirect(rv.Index(i)); item.IsValid() {
+					x.walk(item, elem, fmt.Sprintf("%s_%d_", key, i), template, seen)
+				}
+			}
+		case mapVar:
+			elem := structType(field.Type.Elem())
+			x.walk(reflect.Value{}, elem, key+"_<name>_", true, seen)
+			if !rv.IsValid() {
+				return
+			}
+			keys := rv.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+			for _, k := range keys {
+				if item := reflect.Indirect(rv.MapIndex(k)); item.IsValid() {
+					x.walk(item, elem, key+"_"+k.String()+"_", template, seen)
+				}
+			}
+		default:
+			value := secretPlaceholder
+			if !isSecret(field) || template {
+				value = ""
+				if rv.IsValid() {
+					value = quoteDotenv(formatDefault(rv, field))
+				}
+			}
+			x.add(key, field.Tag.Get("desc"), describeField(field), value, template)
+		}
+	})
+}
+
+// add writes a variable preceded by its description and type.
+func (x *example) add(key, desc, typ, value string, template bool) {
+	if x.buf.Len() > 0 {
+		x.buf.WriteString("\n")
+	}
+	if desc != "" {
+		x.buf.WriteString("# " + desc + "\n")
+	}
+	x.buf.WriteString("# " + typ + "\n")
+	if template {
+		x.buf.WriteString("# ")
+	}
+	x.buf.WriteString(key + "=" + value + "\n")
+}
+
+// describeField returns the type and options of a field for Example.
+func describeField(field reflect.StructField) string {
+	s := fieldType(field)
+	if hasOption(field, "required") {
+		s += ", required"
+	}
+	if isSecret(field) {
+		s += ", secret"
+	}
+	return s
+}
+
+// fieldType describes the type of a field's values. For enumerated types,
+// it returns the allowed names.
+func fieldType(field reflect.StructField) string {
+	typ := field.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if enum, _ := fieldEnum(field); enum != nil {
+		if typ.Kind() == reflect.Slice {
+			return "list of: " + strings.Join(enum.names, ", ")
+		}
+		return "one of: " + strings.Join(enum.names, ", ")
+	}
+	return typ.String()
+}
+
+// Drift compares the variables in e, e.g. a .env or .env.example file
+// read with LoadDotenv, with the variables that Bind reads into struct v.
+// missing are the variables of v that are not set in e, and unknown the
+// variables in e that don't correspond to any field of v.
+//
+// Unknown variables are only detected if e implements Lister. Variables
+// that belong to slices and maps of structs are not checked.
+func Drift(e Env, v interface{}) (missing, unknown []string, err error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, ErrNotStruct
+	}
+
+	var (
+		names    = varNames(rv.Type(), map[reflect.Type]bool{})
+		known    = map[string]bool{}
+		prefixes []string
+	)
+	for _, name := range names {
+		if strings.HasSuffix(name, "_") {
+			prefixes = append(prefixes, name)
+			continue
+		}
+		known[name] = true
+		if _, ok := e.Lookup(name); !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+outer:
+	for _, key := range Keys(e, "") {
+		if known[key] {
+			continue
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				continue outer
+			}
+		}
+		unknown = append(unknown, key)
+	}
+	return missing, unknown, nil
+}
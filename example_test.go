@@ -0,0 +1,202 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exampleConfig struct {
+	Host      string        `env:",required" desc:"Address to listen on."`
+	Port      int           `desc:"Port to listen on."`
+	Timeout   time.Duration `env:"TIMEOUT"`
+	Password  string        `env:",required,secret"`
+	Level     testLevel
+	Tags      []string
+	Upstreams []Upstream
+	Ignored   string `env:"-"`
+}
+
+func TestExample(t *testing.T) {
+	v := exampleConfig{
+		Host:     "localhost",
+		Port:     8080,
+		Timeout:  time.Second,
+		Password: "hunter2",
+		Level:    levelInfo,
+		Tags:     []string{"a b", "c"},
+
+		Upstreams: []Upstream{},
+	}
+
+	x := `# Address to listen on.
+# string, required
+HOST=localhost
+
+# Port to listen on.
+# int
+PORT=8080
+
+# time.Duration
+TIMEOUT=1s
+
+# string, required, secret
+PASSWORD=<secret>
+
+# one of: WARN2, debug, info, warn
+LEVEL=info
+
+# []string
+TAGS="a b,c"
+
+# int, number of items
+UPSTREAMS_COUNT=0
+
+# string
+# UPSTREAMS_<n>_HOST=
+
+# int
+# UPSTREAMS_<n>_PORT=
+
+# []string
+# UPSTREAMS_<n>_TAGS=
+
+# string
+# UPSTREAMS_<n>_NESTED_STRING=
+
+# int
+# UPSTREAMS_<n>_NESTED_NUM=
+`
+
+	data, err := Example(v)
+	require.NoError(t, err, "example failed")
+	assert.Equal(t, x, string(data), "unexpected result")
+
+	// output is a valid dotenv file
+	e, err := ParseDotenv(bytes.NewReader(data))
+	require.NoError(t, err, "parse failed")
+	var v2 exampleConfig
+	require.NoError(t, Bind(&v2, e), "bind failed")
+	assert.Equal(t, "<secret>", v2.Password, "unexpected password")
+	v2.Password = v.Password
+	assert.Equal(t, v, v2, "unexpected result")
+
+	_, err = Example("x")
+	assert.Equal(t, ErrNotStruct, err, "unexpected error")
+}
+
+// Example lists the variables Bind reads, so Drift finds none missing.
+func TestExample_nested(t *testing.T) {
+	type db struct {
+		Host string
+		Port int
+	}
+	type item struct {
+		Name string
+	}
+	v := struct {
+		Name  string
+		DB    *db
+		Items []item
+		Named map[string]*item
+	}{
+		Name:  "a",
+		Items: []item{{"x"}},
+		Named: map[string]*item{"B": {"y"}, "A": nil},
+	}
+
+	x := `# string
+NAME=a
+
+# string
+HOST=
+
+# int
+PORT=
+
+# int, number of items
+ITEMS_COUNT=1
+
+# string
+# ITEMS_<n>_NAME=
+
+# string
+ITEMS_0_NAME=x
+
+# string
+# NAMED_<name>_NAME=
+
+# string
+NAMED_B_NAME=y
+`
+
+	data, err := Example(&v)
+	require.NoError(t, err, "example failed")
+	assert.Equal(t, x, string(data), "unexpected result")
+
+	e, err := ParseDotenv(bytes.NewReader(data))
+	require.NoError(t, err, "parse failed")
+	missing, unknown, err := Drift(e, &v)
+	require.NoError(t, err, "drift failed")
+	assert.Nil(t, missing, "unexpected missing")
+	assert.Nil(t, unknown, "unexpected unknown")
+
+	data, err = Example(&v, IgnoreZeroValues)
+	require.NoError(t, err, "example failed")
+	assert.NotContains(t, string(data), "HOST=", "zero value written")
+}
+
+func TestDrift(t *testing.T) {
+	e := MapEnv{
+		"HOST":             "localhost",
+		"PROT":             "80",
+		"TIMEOUT":          "",
+		"UPSTREAMS_0_HOST": "a",
+		"IGNORED":          "x",
+	}
+
+	missing, unknown, err := Drift(e, &exampleConfig{})
+	require.NoError(t, err, "drift failed")
+	assert.Equal(t, []string{"LEVEL", "PASSWORD", "PORT", "TAGS"}, missing, "unexpected missing")
+	assert.Equal(t, []string{"IGNORED", "PROT"}, unknown, "unexpected unknown")
+
+	_, _, err = Drift(e, 1)
+	assert.Equal(t, ErrNotStruct, err, "unexpected error")
+}
+
+// Generate a .env.example file.
+func ExampleExample() {
+	cfg := struct {
+		Listen string        `desc:"Address to listen on."`
+		APIKey string        `env:"API_KEY,required,secret"`
+		Delay  time.Duration `desc:"Time to wait between requests."`
+	}{
+		Listen: "localhost:8080",
+		Delay:  time.Second * 5,
+	}
+
+	data, err := Example(cfg)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(data))
+
+	// Output:
+	// # Address to listen on.
+	// # string
+	// LISTEN=localhost:8080
+	//
+	// # string, required, secret
+	// API_KEY=<secret>
+	//
+	// # Time to wait between requests.
+	// # time.Duration
+	// DELAY=5s
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Guards typeParsers against RegisterParser.
+var parsersMu sync.RWMutex
+
+// lookupParser returns the parser registered for typ.
+func lookupParser(typ reflect.Type) (parseFunc, bool) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	fun, ok := typeParsers[typ]
+	return fun, ok
+}
+
+// hasTypeParser returns true if a parser is registered for typ itself.
+func hasTypeParser(typ reflect.Type) bool {
+	_, ok := lookupParser(typ)
+	return ok
+}
+
+// RegisterParser registers a function to parse values of type T. It is
+// used by Bind, the Lookup* functions and GetAs, and takes precedence over
+// the built-in parsers and encoding.TextUnmarshaler.
+//
+//	env.RegisterParser(mail.ParseAddress) // *mail.Address
+//
+// A parser registered for a pointer type *T is also used for values of
+// type T.
+//
+// A parser registered for a slice type receives the whole value, which
+// is not split on commas.
+//
+// Dump writes values of type T to a single variable, even if T is a
+// struct. They are formatted with encoding.TextMarshaler or fmt.Stringer
+// if T or *T implements either, otherwise with fmt.Sprint, so implement
+// one of them for the output to round-trip.
+func RegisterParser[T any](fun func(s string) (T, error)) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	typeParsers[reflect.TypeOf((*T)(nil)).Elem()] = func(s string) (interface{}, error) {
+		return fun(s)
+	}
+}
+
+// GetAs returns the value of envvar "key" in r as a T. Values are parsed
+// by the same parsers as Bind uses for fields of type T, including those
+// registered with RegisterParser and RegisterEnum. Slices are split on
+// commas and map[string]string values have the form "key=value,...".
+//
+// It accepts one optional "fallback" argument, which is returned (with a
+// nil error) if the variable is unset or empty. If the value is invalid,
+// GetAs returns the fallback and a *ParseError.
+//
+//	port, err := env.GetAs[uint16](env.Default(), "PORT", 80)
+func GetAs[T any](r Reader, key string, fallback ...T) (T, error) {
+	var fb T
+	if len(fallback) > 0 {
+		fb = fallback[0]
+	}
+
+	v, ok, err := r.lookupValue(key, reflect.TypeOf((*T)(nil)).Elem())
+	r.errs.add(r.prefix+key, ok, err, len(fallback) == 0)
+	if !ok || err != nil {
+		return fb, err
+	}
+	return v.Interface().(T), nil
+}
+
+// LoadAs returns a T, which must be a struct type, populated from env or
+// the program's environment by Bind.
+//
+//	cfg, err := env.LoadAs[Config]()
+//
+// It is named LoadAs because Load adds .env files to the environment.
+func LoadAs[T any](env ...Env) (T, error) {
+	var v T
+	err := Bind(&v, env...)
+	return v, err
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// test types with registered parsers
+type (
+	testTags  []string
+	testPoint struct{ X, Y int }
+	testPair  struct{ A, B string }
+)
+
+func (p testPoint) String() string { return fmt.Sprintf("%d/%d", p.X, p.Y) }
+
+// unregisterParser removes the parser registered for T. It undoes
+// RegisterParser.
+func unregisterParser[T any]() {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	delete(typeParsers, reflect.TypeOf((*T)(nil)).Elem())
+}
+
+func parseTestPoint(s string) (testPoint, error) {
+	var p testPoint
+	_, err := fmt.Sscanf(s, "%d/%d", &p.X, &p.Y)
+	return p, err
+}
+
+func TestGetAs(t *testing.T) {
+	RegisterParser(func(s string) (testTags, error) {
+		if s == "none" {
+			return nil, errors.New("no tags")
+		}
+		return testTags(strings.Split(s, "+")), nil
+	})
+	defer unregisterParser[testTags]()
+
+	r := New(MapEnv{
+		"PORT":     "8080",
+		"BIG_PORT": "80000",
+		"TIMEOUT":  "5s",
+		"HOSTS":    "a,b",
+		"LEVELS":   "warn,debug",
+		"LABELS":   "env=prod",
+		"TAGS":     "a+b,c",
+		"NO_TAGS":  "none",
+		"ADMIN":    "Bob <bob@example.com>",
+		"EMPTY":    "",
+	})
+
+	port, err := GetAs[uint16](r, "PORT")
+	require.NoError(t, err, "GetAs failed")
+	assert.Equal(t, uint16(8080), port, "unexpected PORT")
+
+	port, err = GetAs[uint16](r, "BIG_PORT", 80)
+	assert.Equal(t, uint16(80), port, "unexpected BIG_PORT")
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe), "not a *ParseError: %v", err)
+	assert.Equal(t, "BIG_PORT", pe.Var, "unexpected Var")
+
+	port, err = GetAs[uint16](r, "EMPTY", 80)
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, uint16(80), port, "unexpected EMPTY")
+
+	d, err := GetAs[time.Duration](r, "TIMEOUT")
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, 5*time.Second, d, "unexpected TIMEOUT")
+
+	hosts, err := GetAs[[]string](r, "HOSTS")
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, []string{"a", "b"}, hosts, "unexpected HOSTS")
+
+	levels, err := GetAs[[]testLevel](r, "LEVELS")
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, []testLevel{levelWarn, levelDebug}, levels, "unexpected LEVELS")
+
+	labels, err := GetAs[map[string]string](r, "LABELS")
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, map[string]string{"env": "prod"}, labels, "unexpected LABELS")
+
+	tags, err := GetAs[testTags](r, "TAGS")
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, testTags{"a", "b,c"}, tags, "unexpected TAGS")
+	_, err = GetAs[testTags](r, "NO_TAGS")
+	assert.EqualError(t, err, "invalid value for NO_TAGS: no tags", "unexpected error")
+
+	_, err = GetAs[chan int](r, "PORT")
+	assert.True(t, errors.Is(err, ErrUnsupported("chan int")), "unexpected error: %v", err)
+
+	// registered parsers are used by Bind
+	v := struct {
+		Tags  testTags
+		Admin *mail.Address
+	}{}
+	RegisterParser(mail.ParseAddress)
+	defer unregisterParser[*mail.Address]()
+	require.NoError(t, Bind(&v, r.env), "bind failed")
+	assert.Equal(t, testTags{"a", "b,c"}, v.Tags, "unexpected Tags")
+	assert.Equal(t, &mail.Address{Name: "Bob", Address: "bob@example.com"}, v.Admin, "unexpected Admin")
+}
+
+func TestDumpRegisteredParser(t *testing.T) {
+	RegisterParser(parseTestPoint)
+	defer unregisterParser[testPoint]()
+	RegisterParser(func(s string) (testPair, error) { return testPair{}, nil })
+	defer unregisterParser[testPair]()
+	// registered for *T, but also used for fields of type T
+	RegisterParser(mail.ParseAddress)
+	defer unregisterParser[*mail.Address]()
+
+	type config struct {
+		Origin testPoint
+		Cursor *testPoint
+		Path   []testPoint
+		Pair   testPair
+		Admin  mail.Address
+		Owner  *mail.Address
+		Count  big.Int // TextMarshaler on *big.Int
+	}
+
+	v := config{
+		Origin: testPoint{1, 2},
+		Cursor: &testPoint{3, 4},
+		Path:   []testPoint{{5, 6}, {7, 8}},
+		Pair:   testPair{"a", "b"},
+		Admin:  mail.Address{Name: "Bob", Address: "bob@example.com"},
+		Owner:  &mail.Address{Address: "alice@example.com"},
+	}
+	v.Count.SetInt64(42)
+	m, err := Dump(v)
+	require.NoError(t, err, "Dump failed")
+	assert.Equal(t, map[string]string{
+		"ORIGIN": "1/2",
+		"CURSOR": "3/4",
+		"PATH":   "5/6,7/8",
+		"PAIR":   "{a b}", // no Stringer or TextMarshaler
+		"ADMIN":  `"Bob" <bob@example.com>`,
+		"OWNER":  "<alice@example.com>",
+		"COUNT":  "42",
+	}, m, "unexpected variables")
+
+	var v2 config
+	require.NoError(t, Bind(&v2, MapEnv(m)), "Bind failed")
+	assert.Equal(t, v.Origin, v2.Origin, "unexpected Origin")
+	assert.Equal(t, v.Cursor, v2.Cursor, "unexpected Cursor")
+	assert.Equal(t, v.Path, v2.Path, "unexpected Path")
+	assert.Equal(t, v.Admin, v2.Admin, "unexpected Admin")
+	assert.Equal(t, v.Owner, v2.Owner, "unexpected Owner")
+	assert.Equal(t, 0, v.Count.Cmp(&v2.Count), "unexpected Count")
+}
+
+func TestLoadAs(t *testing.T) {
+	type config struct {
+		Host string
+		Port int
+	}
+
+	cfg, err := LoadAs[config](MapEnv{"HOST": "localhost", "PORT": "8080"})
+	require.NoError(t, err, "LoadAs failed")
+	assert.Equal(t, config{"localhost", 8080}, cfg, "unexpected config")
+
+	_, err = LoadAs[config](MapEnv{"PORT": "80a"})
+	assert.Error(t, err, "accepted invalid PORT")
+
+	_, err = LoadAs[int]()
+	assert.Equal(t, ErrNotStructPtr, err, "accepted int")
+}
+
+// Read typed values with one function.
+func ExampleGetAs() {
+	_ = os.Setenv("PORT", "8080")
+	_ = os.Setenv("HOSTS", "a.example.com,b.example.com")
+
+	r := New(System)
+	port, err := GetAs[uint16](r, "PORT", 80)
+	fmt.Println(port, err)
+	hosts, err := GetAs[[]string](r, "HOSTS")
+	fmt.Println(hosts, err)
+
+	// Output:
+	// 8080 <nil>
+	// [a.example.com b.example.com] <nil>
+
+	os.Clearenv()
+}
+
+// Populate a struct from the environment.
+func ExampleLoadAs() {
+	_ = os.Setenv("HOST", "localhost")
+	_ = os.Setenv("PORT", "8080")
+
+	type config struct {
+		Host string
+		Port int
+	}
+
+	cfg, err := LoadAs[config]()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(cfg.Host, cfg.Port)
+
+	// Output:
+	// localhost 8080
+
+	os.Clearenv()
+}
@@ -0,0 +1,419 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lookup returns the value of key. ok is false if key is unset or empty.
+// If the Env is a FallibleEnv and the lookup fails, ok is false and err
+// is the lookup error.
+func (r Reader) lookup(key string) (value string, ok bool, err error) {
+	value, _, ok, err = lookupSource(r.env, key)
+	if err != nil {
+		return "", false, fmt.Errorf("%s: %w", r.prefix+key, err)
+	}
+	return value, ok && value != "", nil
+}
+
+// lookupValue returns the value of key parsed into a value of type typ
+// by the parsers Bind uses. Slices are split like slice fields, and
+// the names registered with RegisterEnum are accepted for enum types.
+func (r Reader) lookupValue(key string, typ reflect.Type) (value reflect.Value, ok bool, err error) {
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return reflect.Value{}, ok, err
+	}
+
+	value = reflect.New(typ).Elem()
+	enum := lookupEnum(enumType(typ))
+	switch {
+	case typ == stringMapType:
+		var m map[string]string
+		if m, err = parseStringMap(s); err == nil {
+			value.Set(reflect.ValueOf(m))
+		}
+	case typ.Kind() == reflect.Slice && !hasTypeParser(typ):
+		err = setSlice(value, s, enum)
+	default:
+		var v reflect.Value
+		if v, err = parseItem(typ, s, enum); err == nil {
+			value.Set(v)
+		}
+	}
+	if err != nil {
+		return reflect.Value{}, true, r.parseError(key, err)
+	}
+	return value, true, nil
+}
+
+var stringMapType = reflect.TypeOf(map[string]string{})
+
+// parseStringMap parses a string of the form "key=value,key2=value2".
+func parseStringMap(s string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, item := range splitList(s) {
+		i := strings.Index(item, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid map item %q (expected key=value)", item)
+		}
+		m[item[:i]] = item[i+1:]
+	}
+	return m, nil
+}
+
+// parseError returns a *ParseError for key, which is prefixed with the
+// Reader's prefix (see Sub) to name the variable.
+func (r Reader) parseError(key string, err error) error {
+	return &ParseError{Var: r.prefix + key, Err: err}
+}
+
+// LookupString returns the value of envvar "key". ok is false if the
+// variable is unset or empty.
+func LookupString(key string) (value string, ok bool, err error) {
+	return Default().LookupString(key)
+}
+
+// LookupString returns the value of envvar "key". ok is false if the
+// variable is unset or empty.
+func (r Reader) LookupString(key string) (value string, ok bool, err error) {
+	return r.lookup(key)
+}
+
+// LookupInt returns the value of envvar "key" as an int, parsed like
+// GetInt. ok is false if the variable is unset or empty, and err is a
+// *ParseError if the value is invalid.
+func LookupInt(key string) (value int, ok bool, err error) {
+	return Default().LookupInt(key)
+}
+
+// LookupInt returns the value of envvar "key" as an int, parsed like
+// GetInt. ok is false if the variable is unset or empty, and err is a
+// *ParseError if the value is invalid.
+func (r Reader) LookupInt(key string) (value int, ok bool, err error) {
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if value, err = parseInt(s); err != nil {
+		return 0, true, r.parseError(key, err)
+	}
+	return value, true, nil
+}
+
+// LookupUint returns the value of envvar "key" as a uint, parsed like
+// GetUint. ok is false if the variable is unset or empty, and err is a
+// *ParseError if the value is invalid.
+func LookupUint(key string) (value uint, ok bool, err error) {
+	return Default().LookupUint(key)
+}
+
+// LookupUint returns the value of envvar "key" as a uint, parsed like
+// GetUint. ok is false if the variable is unset or empty, and err is a
+// *ParseError if the value is invalid.
+func (r Reader) LookupUint(key string) (value uint, ok bool, err error) {
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if value, err = parseUint(s); err != nil {
+		return 0, true, r.parseError(key, err)
+	}
+	return value, true, nil
+}
+
+// LookupFloat returns the value of envvar "key" as a float, parsed like
+// GetFloat. ok is false if the variable is unset or empty, and err is a
+// *ParseError if the value is invalid.
+func LookupFloat(key string) (value float64, ok bool, err error) {
+	return Default().LookupFloat(key)
+}
+
+// LookupFloat returns the value of envvar "key" as a float, parsed like
+// GetFloat. ok is false if the variable is unset or empty, and err is a
+// *ParseError if the value is invalid.
+func (r Reader) LookupFloat(key string) (value float64, ok bool, err error) {
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if value, err = strconv.ParseFloat(s, 64); err != nil {
+		return 0, true, r.parseError(key, err)
+	}
+	return value, true, nil
+}
+
+// LookupComplex returns the value of envvar "key" as a complex number,
+// parsed like GetComplex. ok is false if the variable is unset or empty,
+// and err is a *ParseError if the value is invalid.
+func LookupComplex(key string) (value complex128, ok bool, err error) {
+	return Default().LookupComplex(key)
+}
+
+// LookupComplex returns the value of envvar "key" as a complex number,
+// parsed like GetComplex. ok is false if the variable is unset or empty,
+// and err is a *ParseError if the value is invalid.
+func (r Reader) LookupComplex(key string) (value complex128, ok bool, err error) {
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if value, err = parseComplex(s, 128); err != nil {
+		return 0, true, r.parseError(key, err)
+	}
+	return value, true, nil
+}
+
+// LookupBigInt returns the value of envvar "key" as a *big.Int, parsed
+// like GetBigInt. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func LookupBigInt(key string) (value *big.Int, ok bool, err error) {
+	return Default().LookupBigInt(key)
+}
+
+// LookupBigInt returns the value of envvar "key" as a *big.Int, parsed
+// like GetBigInt. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func (r Reader) LookupBigInt(key string) (value *big.Int, ok bool, err error) {
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if value, err = parseBigInt(s); err != nil {
+		return nil, true, r.parseError(key, err)
+	}
+	return value, true, nil
+}
+
+// LookupBigFloat returns the value of envvar "key" as a *big.Float, parsed
+// like GetBigFloat. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func LookupBigFloat(key string) (value *big.Float, ok bool, err error) {
+	return Default().LookupBigFloat(key)
+}
+
+// LookupBigFloat returns the value of envvar "key" as a *big.Float, parsed
+// like GetBigFloat. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func (r Reader) LookupBigFloat(key string) (value *big.Float, ok bool, err error) {
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if value, err = parseBigFloat(s); err != nil {
+		return nil, true, r.parseError(key, err)
+	}
+	return value, true, nil
+}
+
+// LookupBigRat returns the value of envvar "key" as a *big.Rat, parsed
+// like GetBigRat. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func LookupBigRat(key string) (value *big.Rat, ok bool, err error) {
+	return Default().LookupBigRat(key)
+}
+
+// LookupBigRat returns the value of envvar "key" as a *big.Rat, parsed
+// like GetBigRat. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func (r Reader) LookupBigRat(key string) (value *big.Rat, ok bool, err error) {
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if value, err = parseBigRat(s); err != nil {
+		return nil, true, r.parseError(key, err)
+	}
+	return value, true, nil
+}
+
+// LookupDuration returns the value of envvar "key" as a time.Duration,
+// parsed like GetDuration. ok is false if the variable is unset or empty,
+// and err is a *ParseError if the value is invalid.
+func LookupDuration(key string) (value time.Duration, ok bool, err error) {
+	return Default().LookupDuration(key)
+}
+
+// LookupDuration returns the value of envvar "key" as a time.Duration,
+// parsed like GetDuration. ok is false if the variable is unset or empty,
+// and err is a *ParseError if the value is invalid.
+func (r Reader) LookupDuration(key string) (value time.Duration, ok bool, err error) {
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if value, err = time.ParseDuration(s); err != nil {
+		return 0, true, r.parseError(key, err)
+	}
+	return value, true, nil
+}
+
+// LookupBool returns the value of envvar "key" as a boolean, parsed like
+// GetBool. ok is false if the variable is unset or empty, and err is a
+// *ParseError if the value is invalid.
+func LookupBool(key string) (value bool, ok bool, err error) {
+	return Default().LookupBool(key)
+}
+
+// LookupBool returns the value of envvar "key" as a boolean, parsed like
+// GetBool. ok is false if the variable is unset or empty, and err is a
+// *ParseError if the value is invalid.
+func (r Reader) LookupBool(key string) (value bool, ok bool, err error) {
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return false, ok, err
+	}
+	if value, err = strconv.ParseBool(s); err != nil {
+		return false, true, r.parseError(key, err)
+	}
+	return value, true, nil
+}
+
+// LookupEnum returns the value of envvar "key" converted to the type of
+// typ via the names registered with RegisterEnum. ok is false if the
+// variable is unset or empty, and err is a *ParseError if the value isn't
+// a valid name.
+//
+// LookupEnum panics if no names have been registered for typ's type.
+func LookupEnum(key string, typ interface{}) (value interface{}, ok bool, err error) {
+	return Default().LookupEnum(key, typ)
+}
+
+// LookupEnum returns the value of envvar "key" converted to the type of
+// typ via the names registered with RegisterEnum. ok is false if the
+// variable is unset or empty, and err is a *ParseError if the value isn't
+// a valid name.
+//
+// LookupEnum panics if no names have been registered for typ's type.
+func (r Reader) LookupEnum(key string, typ interface{}) (value interface{}, ok bool, err error) {
+	t := lookupEnum(reflect.TypeOf(typ))
+	if t == nil {
+		panic(fmt.Sprintf("env: no enum registered for type %T", typ))
+	}
+
+	s, ok, err := r.lookup(key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	rv, err := t.parse(s)
+	if err != nil {
+		return nil, true, r.parseError(key, err)
+	}
+	return rv.Interface(), true, nil
+}
+
+// LookupStrings returns the value of envvar "key" as a slice of strings, parsed
+// like GetStrings. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func LookupStrings(key string) (value []string, ok bool, err error) {
+	return Default().LookupStrings(key)
+}
+
+// LookupStrings returns the value of envvar "key" as a slice of strings, parsed
+// like GetStrings. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func (r Reader) LookupStrings(key string) (value []string, ok bool, err error) {
+	v, ok, err := r.lookupValue(key, reflect.TypeOf([]string{}))
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	return v.Interface().([]string), true, nil
+}
+
+// LookupInts returns the value of envvar "key" as a slice of ints, parsed
+// like GetInts. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func LookupInts(key string) (value []int, ok bool, err error) {
+	return Default().LookupInts(key)
+}
+
+// LookupInts returns the value of envvar "key" as a slice of ints, parsed
+// like GetInts. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func (r Reader) LookupInts(key string) (value []int, ok bool, err error) {
+	v, ok, err := r.lookupValue(key, reflect.TypeOf([]int{}))
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	return v.Interface().([]int), true, nil
+}
+
+// LookupDurations returns the value of envvar "key" as a slice of time.Durations, parsed
+// like GetDurations. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func LookupDurations(key string) (value []time.Duration, ok bool, err error) {
+	return Default().LookupDurations(key)
+}
+
+// LookupDurations returns the value of envvar "key" as a slice of time.Durations, parsed
+// like GetDurations. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func (r Reader) LookupDurations(key string) (value []time.Duration, ok bool, err error) {
+	v, ok, err := r.lookupValue(key, reflect.TypeOf([]time.Duration{}))
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	return v.Interface().([]time.Duration), true, nil
+}
+
+// LookupStringMap returns the value of envvar "key" as a map of strings, parsed
+// like GetStringMap. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func LookupStringMap(key string) (value map[string]string, ok bool, err error) {
+	return Default().LookupStringMap(key)
+}
+
+// LookupStringMap returns the value of envvar "key" as a map of strings, parsed
+// like GetStringMap. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func (r Reader) LookupStringMap(key string) (value map[string]string, ok bool, err error) {
+	v, ok, err := r.lookupValue(key, stringMapType)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	return v.Interface().(map[string]string), true, nil
+}
+
+// LookupURL returns the value of envvar "key" as a *url.URL, parsed
+// like GetURL. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func LookupURL(key string) (value *url.URL, ok bool, err error) {
+	return Default().LookupURL(key)
+}
+
+// LookupURL returns the value of envvar "key" as a *url.URL, parsed
+// like GetURL. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func (r Reader) LookupURL(key string) (value *url.URL, ok bool, err error) {
+	v, ok, err := r.lookupValue(key, reflect.TypeOf(&url.URL{}))
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	return v.Interface().(*url.URL), true, nil
+}
+
+// LookupInt64 returns the value of envvar "key" as an int64, parsed
+// like GetInt64. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func LookupInt64(key string) (value int64, ok bool, err error) {
+	return Default().LookupInt64(key)
+}
+
+// LookupInt64 returns the value of envvar "key" as an int64, parsed
+// like GetInt64. ok is false if the variable is unset or empty, and err
+// is a *ParseError if the value is invalid.
+func (r Reader) LookupInt64(key string) (value int64, ok bool, err error) {
+	v, ok, err := r.lookupValue(key, reflect.TypeOf(int64(0)))
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	return v.Interface().(int64), true, nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	r := New(MapEnv{
+		"STRING":   "value",
+		"INT":      "80",
+		"UINT":     "443",
+		"FLOAT":    "1.5",
+		"COMPLEX":  "1+2i",
+		"BIG_INT":  "0x10",
+		"BIG_RAT":  "3/4",
+		"DURATION": "5s",
+		"BOOL":     "true",
+		"LEVEL":    "warn",
+		"BAD":      "80a",
+		"EMPTY":    "",
+	})
+
+	// valid values
+	s, ok, err := r.LookupString("STRING")
+	assert.Equal(t, "value", s, "unexpected string")
+	assert.True(t, ok, "STRING not found")
+	assert.NoError(t, err, "unexpected error")
+
+	tests := []struct {
+		key string
+		fun func(key string) (interface{}, bool, error)
+		x   interface{}
+	}{
+		{"INT", func(k string) (interface{}, bool, error) { return r.LookupInt(k) }, 80},
+		{"UINT", func(k string) (interface{}, bool, error) { return r.LookupUint(k) }, uint(443)},
+		{"FLOAT", func(k string) (interface{}, bool, error) { return r.LookupFloat(k) }, 1.5},
+		{"COMPLEX", func(k string) (interface{}, bool, error) { return r.LookupComplex(k) }, complex(1, 2)},
+		{"BIG_INT", func(k string) (interface{}, bool, error) { return r.LookupBigInt(k) }, big.NewInt(16)},
+		{"BIG_RAT", func(k string) (interface{}, bool, error) { return r.LookupBigRat(k) }, big.NewRat(3, 4)},
+		{"DURATION", func(k string) (interface{}, bool, error) { return r.LookupDuration(k) }, 5 * time.Second},
+		{"BOOL", func(k string) (interface{}, bool, error) { return r.LookupBool(k) }, true},
+		{"LEVEL", func(k string) (interface{}, bool, error) { return r.LookupEnum(k, levelInfo) }, levelWarn},
+	}
+
+	for _, td := range tests {
+		td := td
+		t.Run(td.key, func(t *testing.T) {
+			v, ok, err := td.fun(td.key)
+			require.NoError(t, err, "lookup failed")
+			assert.True(t, ok, "variable not found")
+			assert.Equal(t, td.x, v, "unexpected value")
+
+			// unset and empty variables
+			for _, key := range []string{"UNSET", "EMPTY"} {
+				_, ok, err = td.fun(key)
+				assert.NoError(t, err, "unexpected error for %s", key)
+				assert.False(t, ok, "%s found", key)
+			}
+
+			// invalid value
+			_, ok, err = td.fun("BAD")
+			assert.True(t, ok, "BAD not found")
+			require.Error(t, err, "accepted invalid value")
+			pe, isPE := err.(*ParseError)
+			require.True(t, isPE, "not a *ParseError: %v", err)
+			assert.Equal(t, "BAD", pe.Var, "unexpected Var")
+		})
+	}
+
+	_, _, err = r.LookupInt("BAD")
+	assert.EqualError(t, err, "invalid value for BAD: invalid int: 80a", "unexpected error")
+	_, _, err = r.LookupFloat("BAD")
+	assert.True(t, errors.Is(err, strconv.ErrSyntax), "unexpected error")
+
+	assert.Panics(t, func() { _, _, _ = r.LookupEnum("LEVEL", 0) }, "unregistered type accepted")
+}
+
+// Errors name the full variable, including the prefixes added by Sub.
+func TestLookup_sub(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err, "generate key")
+	e := DecryptingEnv(MapEnv{"APP_REDIS_PORT": "80a", "APP_REDIS_PASS": EncryptedPrefix + "AAAA"}, key)
+	r := New(e).Sub("APP_").Sub("REDIS_")
+
+	_, _, err = r.LookupInt("PORT")
+	assert.EqualError(t, err, "invalid value for APP_REDIS_PORT: invalid int: 80a", "unexpected error")
+	pe, ok := err.(*ParseError)
+	require.True(t, ok, "not a *ParseError: %v", err)
+	assert.Equal(t, "APP_REDIS_PORT", pe.Var, "unexpected Var")
+
+	_, _, err = r.LookupStrings("PASS")
+	assert.EqualError(t, err, "APP_REDIS_PASS: cannot decrypt value", "unexpected error")
+}
+
+func TestLookup_fallible(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err, "generate key")
+	r := New(DecryptingEnv(MapEnv{"PORT": EncryptedPrefix + "AAAA"}, key))
+
+	_, ok, err := r.LookupInt("PORT")
+	assert.False(t, ok, "PORT found")
+	assert.EqualError(t, err, "PORT: cannot decrypt value", "unexpected error")
+	assert.Equal(t, 8080, r.GetInt("PORT", 8080), "unexpected GetInt")
+}
+
+// Distinguish between unset and invalid values.
+func ExampleLookupInt() {
+	_ = os.Setenv("PORT", "80a")
+
+	port, ok, err := LookupInt("PORT")
+	fmt.Println(port, ok, err)
+
+	_ = os.Setenv("PORT", "8080")
+	port, ok, err = LookupInt("PORT")
+	fmt.Println(port, ok, err)
+
+	port, ok, err = LookupInt("UNSET")
+	fmt.Println(port, ok, err)
+
+	// Output:
+	// 0 true invalid value for PORT: invalid int: 80a
+	// 8080 true <nil>
+	// 0 false <nil>
+
+	os.Clearenv()
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// MustHandler is called by the Must* functions with an error naming the
+// variable if a variable is unset, empty or invalid. The error is a
+// *MissingVarsError, a *ParseError or an error returned by a FallibleEnv.
+//
+// The default handler panics. Replace it to exit instead, e.g.:
+//
+//	env.MustHandler = func(err error) {
+//		fmt.Fprintln(os.Stderr, err)
+//		os.Exit(1)
+//	}
+//
+// If the handler returns, the Must* function returns the zero value.
+// Set MustHandler at startup, not concurrently with calls to Must*.
+var MustHandler = func(err error) { panic(err) }
+
+// must calls MustHandler if key is missing or err is non-nil.
+func (r Reader) must(key string, ok bool, err error) {
+	if err == nil && !ok {
+		err = &MissingVarsError{Vars: []string{r.prefix + key}}
+	}
+	if err != nil {
+		MustHandler(err)
+	}
+}
+
+// MustGet returns the value of envvar "key". If the variable is unset or
+// empty, it calls MustHandler, which panics by default.
+func MustGet(key string) string {
+	return Default().MustGet(key)
+}
+
+// MustGet returns the value of envvar "key". If the variable is unset or
+// empty, it calls MustHandler, which panics by default.
+func (r Reader) MustGet(key string) string {
+	s, ok, err := r.LookupString(key)
+	r.must(key, ok, err)
+	return s
+}
+
+// MustGetInt returns the value of envvar "key" as an int, parsed like
+// GetInt. If the variable is unset, empty or invalid, it calls MustHandler,
+// which panics by default.
+func MustGetInt(key string) int {
+	return Default().MustGetInt(key)
+}
+
+// MustGetInt returns the value of envvar "key" as an int, parsed like
+// GetInt. If the variable is unset, empty or invalid, it calls MustHandler,
+// which panics by default.
+func (r Reader) MustGetInt(key string) int {
+	i, ok, err := r.LookupInt(key)
+	r.must(key, ok, err)
+	return i
+}
+
+// MustGetUint returns the value of envvar "key" as a uint, parsed like
+// GetUint. If the variable is unset, empty or invalid, it calls MustHandler,
+// which panics by default.
+func MustGetUint(key string) uint {
+	return Default().MustGetUint(key)
+}
+
+// MustGetUint returns the value of envvar "key" as a uint, parsed like
+// GetUint. If the variable is unset, empty or invalid, it calls MustHandler,
+// which panics by default.
+func (r Reader) MustGetUint(key string) uint {
+	i, ok, err := r.LookupUint(key)
+	r.must(key, ok, err)
+	return i
+}
+
+// MustGetFloat returns the value of envvar "key" as a float, parsed like
+// GetFloat. If the variable is unset, empty or invalid, it calls
+// MustHandler, which panics by default.
+func MustGetFloat(key string) float64 {
+	return Default().MustGetFloat(key)
+}
+
+// MustGetFloat returns the value of envvar "key" as a float, parsed like
+// GetFloat. If the variable is unset, empty or invalid, it calls
+// MustHandler, which panics by default.
+func (r Reader) MustGetFloat(key string) float64 {
+	n, ok, err := r.LookupFloat(key)
+	r.must(key, ok, err)
+	return n
+}
+
+// MustGetComplex returns the value of envvar "key" as a complex number,
+// parsed like GetComplex. If the variable is unset, empty or invalid, it
+// calls MustHandler, which panics by default.
+func MustGetComplex(key string) complex128 {
+	return Default().MustGetComplex(key)
+}
+
+// MustGetComplex returns the value of envvar "key" as a complex number,
+// parsed like GetComplex. If the variable is unset, empty or invalid, it
+// calls MustHandler, which panics by default.
+func (r Reader) MustGetComplex(key string) complex128 {
+	c, ok, err := r.LookupComplex(key)
+	r.must(key, ok, err)
+	return c
+}
+
+// MustGetBigInt returns the value of envvar "key" as a *big.Int, parsed
+// like GetBigInt. If the variable is unset, empty or invalid, it calls
+// MustHandler, which panics by default.
+func MustGetBigInt(key string) *big.Int {
+	return Default().MustGetBigInt(key)
+}
+
+// MustGetBigInt returns the value of envvar "key" as a *big.Int, parsed
+// like GetBigInt. If the variable is unset, empty or invalid, it calls
+// MustHandler, which panics by default.
+func (r Reader) MustGetBigInt(key string) *big.Int {
+	n, ok, err := r.LookupBigInt(key)
+	r.must(key, ok, err)
+	return n
+}
+
+// MustGetBigFloat returns the value of envvar "key" as a *big.Float, parsed
+// like GetBigFloat. If the variable is unset, empty or invalid, it calls
+// MustHandler, which panics by default.
+func MustGetBigFloat(key string) *big.Float {
+	return Default().MustGetBigFloat(key)
+}
+
+// MustGetBigFloat returns the value of envvar "key" as a *big.Float, parsed
+// like GetBigFloat. If the variable is unset, empty or invalid, it calls
+// MustHandler, which panics by default.
+func (r Reader) MustGetBigFloat(key string) *big.Float {
+	f, ok, err := r.LookupBigFloat(key)
+	r.must(key, ok, err)
+	return f
+}
+
+// MustGetBigRat returns the value of envvar "key" as a *big.Rat, parsed
+// like GetBigRat. If the variable is unset, empty or invalid, it calls
+// MustHandler, which panics by default.
+func MustGetBigRat(key string) *big.Rat {
+	return Default().MustGetBigRat(key)
+}
+
+// MustGetBigRat returns the value of envvar "key" as a *big.Rat, parsed
+// like GetBigRat. If the variable is unset, empty or invalid, it calls
+// MustHandler, which panics by default.
+func (r Reader) MustGetBigRat(key string) *big.Rat {
+	n, ok, err := r.LookupBigRat(key)
+	r.must(key, ok, err)
+	return n
+}
+
+// MustGetDuration returns the value of envvar "key" as a time.Duration,
+// parsed like GetDuration. If the variable is unset, empty or invalid, it
+// calls MustHandler, which panics by default.
+func MustGetDuration(key string) time.Duration {
+	return Default().MustGetDuration(key)
+}
+
+// MustGetDuration returns the value of envvar "key" as a time.Duration,
+// parsed like GetDuration. If the variable is unset, empty or invalid, it
+// calls MustHandler, which panics by default.
+func (r Reader) MustGetDuration(key string) time.Duration {
+	d, ok, err := r.LookupDuration(key)
+	r.must(key, ok, err)
+	return d
+}
+
+// MustGetBool returns the value of envvar "key" as a boolean, parsed like
+// GetBool. If the variable is unset, empty or invalid, it calls MustHandler,
+// which panics by default.
+func MustGetBool(key string) bool {
+	return Default().MustGetBool(key)
+}
+
+// MustGetBool returns the value of envvar "key" as a boolean, parsed like
+// GetBool. If the variable is unset, empty or invalid, it calls MustHandler,
+// which panics by default.
+func (r Reader) MustGetBool(key string) bool {
+	b, ok, err := r.LookupBool(key)
+	r.must(key, ok, err)
+	return b
+}
+
+// MustGetEnum returns the value of envvar "key" converted to the type of
+// typ via the names registered with RegisterEnum. If the variable is unset,
+// empty or invalid, it calls MustHandler, which panics by default.
+func MustGetEnum(key string, typ interface{}) interface{} {
+	return Default().MustGetEnum(key, typ)
+}
+
+// MustGetEnum returns the value of envvar "key" converted to the type of
+// typ via the names registered with RegisterEnum. If the variable is unset,
+// empty or invalid, it calls MustHandler, which panics by default.
+func (r Reader) MustGetEnum(key string, typ interface{}) interface{} {
+	v, ok, err := r.LookupEnum(key, typ)
+	r.must(key, ok, err)
+	if v == nil {
+		return reflect.Zero(reflect.TypeOf(typ)).Interface()
+	}
+	return v
+}
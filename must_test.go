@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recoverErr returns the error fun panics with.
+func recoverErr(fun func()) (err error) {
+	defer func() { err, _ = recover().(error) }()
+	fun()
+	return nil
+}
+
+func TestMust(t *testing.T) {
+	r := New(MapEnv{
+		"STRING":    "value",
+		"INT":       "80",
+		"UINT":      "443",
+		"FLOAT":     "1.5",
+		"COMPLEX":   "1+2i",
+		"BIG_INT":   "16",
+		"BIG_FLOAT": "2.5",
+		"BIG_RAT":   "3/4",
+		"DURATION":  "5s",
+		"BOOL":      "true",
+		"LEVEL":     "warn",
+		"BAD":       "80a",
+		"EMPTY":     "",
+	})
+
+	tests := []struct {
+		key string
+		fun func(key string) interface{}
+		x   interface{}
+	}{
+		{"STRING", func(k string) interface{} { return r.MustGet(k) }, "value"},
+		{"INT", func(k string) interface{} { return r.MustGetInt(k) }, 80},
+		{"UINT", func(k string) interface{} { return r.MustGetUint(k) }, uint(443)},
+		{"FLOAT", func(k string) interface{} { return r.MustGetFloat(k) }, 1.5},
+		{"COMPLEX", func(k string) interface{} { return r.MustGetComplex(k) }, complex(1, 2)},
+		{"BIG_INT", func(k string) interface{} { return r.MustGetBigInt(k) }, big.NewInt(16)},
+		{"BIG_FLOAT", func(k string) interface{} { return r.MustGetBigFloat(k).String() }, "2.5"},
+		{"BIG_RAT", func(k string) interface{} { return r.MustGetBigRat(k) }, big.NewRat(3, 4)},
+		{"DURATION", func(k string) interface{} { return r.MustGetDuration(k) }, 5 * time.Second},
+		{"BOOL", func(k string) interface{} { return r.MustGetBool(k) }, true},
+		{"LEVEL", func(k string) interface{} { return r.MustGetEnum(k, levelInfo) }, levelWarn},
+	}
+
+	for _, td := range tests {
+		td := td
+		t.Run(td.key, func(t *testing.T) {
+			assert.Equal(t, td.x, td.fun(td.key), "unexpected value")
+			assert.EqualError(t, recoverErr(func() { td.fun("UNSET") }),
+				"missing required variable: UNSET", "unexpected panic")
+			assert.EqualError(t, recoverErr(func() { td.fun("EMPTY") }),
+				"missing required variable: EMPTY", "unexpected panic")
+			if td.key != "STRING" {
+				_, isPE := recoverErr(func() { td.fun("BAD") }).(*ParseError)
+				assert.True(t, isPE, "invalid value accepted")
+			}
+		})
+	}
+}
+
+func TestMust_sub(t *testing.T) {
+	r := New(MapEnv{"REDIS_PORT": "x"}).Sub("REDIS_")
+	assert.EqualError(t, recoverErr(func() { r.MustGetInt("HOST") }),
+		"missing required variable: REDIS_HOST", "unexpected panic")
+	assert.EqualError(t, recoverErr(func() { r.MustGetInt("PORT") }),
+		"invalid value for REDIS_PORT: invalid int: x", "unexpected panic")
+}
+
+func TestMustHandler(t *testing.T) {
+	var errs []string
+	defer func(fun func(error)) { MustHandler = fun }(MustHandler)
+	MustHandler = func(err error) { errs = append(errs, err.Error()) }
+
+	r := New(MapEnv{"PORT": "80a"})
+	assert.Equal(t, 0, r.MustGetInt("PORT"), "unexpected PORT")
+	assert.Equal(t, "", r.MustGet("HOST"), "unexpected HOST")
+	assert.Equal(t, levelDebug, r.MustGetEnum("LEVEL", levelInfo), "unexpected LEVEL")
+	assert.Equal(t, []string{
+		"invalid value for PORT: invalid int: 80a",
+		"missing required variable: HOST",
+		"missing required variable: LEVEL",
+	}, errs, "unexpected errors")
+}
+
+// Exit if required variables are missing.
+func ExampleMustHandler() {
+	defer func(fun func(error)) { MustHandler = fun }(MustHandler)
+	MustHandler = func(err error) {
+		fmt.Println(err)
+		// os.Exit(1)
+	}
+
+	_ = os.Setenv("TIMEOUT", "5")
+
+	_ = MustGet("API_URL")
+	_ = MustGetDuration("TIMEOUT")
+
+	// Output:
+	// missing required variable: API_URL
+	// invalid value for TIMEOUT: time: missing unit in duration "5"
+
+	os.Clearenv()
+}
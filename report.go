@@ -0,0 +1,133 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Masked replaces the values of secret fields in a Report.
+const Masked = "********"
+
+// FieldSource describes where the value of a struct field came from.
+type FieldSource struct {
+	Field   string // path of the field, e.g. "DB.Host" or "Upstreams[0].Port"
+	Var     string // name of the variable the field is bound to
+	Value   string // raw value of the variable, or the field's default value
+	Source  string // name of the Env that supplied the value (see SourceName)
+	Default bool   // true if the variable was unset and the field kept its value
+	Secret  bool   // true if the field is a secret (see Report)
+}
+
+// String returns a description of the field's source.
+func (fs FieldSource) String() string {
+	src := fs.Source
+	if fs.Default {
+		src = "default"
+	}
+	return fs.Field + ": " + fs.Var + "=" + fs.Value + " (" + src + ")"
+}
+
+// Report lists the sources of the fields populated by BindWith in the
+// order they were visited. Create a report with WithReport.
+//
+// The values of secret fields, i.e. fields of type Secret and fields whose
+// `env` tag has the "secret" option, e.g. `env:"API_KEY,secret"` or
+// `env:",secret"`, are replaced with Masked.
+type Report []FieldSource
+
+// Get returns the source of the field with path field.
+func (r Report) Get(field string) (FieldSource, bool) {
+	for _, fs := range r {
+		if fs.Field == field {
+			return fs, true
+		}
+	}
+	return FieldSource{}, false
+}
+
+// String returns one line per field.
+func (r Report) String() string {
+	lines := make([]string, len(r))
+	for i, fs := range r {
+		lines[i] = fs.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WithReport makes BindWith record the source of each field in r.
+// Any existing contents of r are discarded.
+//
+// Sources are named with SourceName, so label the Envs passed to
+// BindWith with Named to get meaningful names, e.g.:
+//
+//	var r env.Report
+//	e := env.Chain(env.Named("flags", flagEnv), env.System)
+//	err := env.BindWith(&cfg, e, env.WithReport(&r))
+func WithReport(r *Report) BindOption {
+	return func(b *binder) {
+		b.report = r
+	}
+}
+
+// record adds a field to the report. If source is nil, the field's current
+// value is recorded as its default.
+func (b *binder) record(rv reflect.Value, field reflect.StructField, path, key, value string, source Env) {
+	if b.report == nil {
+		return
+	}
+
+	fs := FieldSource{
+		Field:  path,
+		Var:    key,
+		Value:  value,
+		Secret: isSecret(field),
+	}
+	if source == nil {
+		fs.Default = true
+		fs.Value = formatDefault(rv, field)
+	} else {
+		fs.Source = SourceName(source)
+	}
+	if fs.Secret && fs.Value != "" {
+		fs.Value = Masked
+	}
+	*b.report = append(*b.report, fs)
+}
+
+// recorded returns the number of fields in the report.
+func (b *binder) recorded() int {
+	if b.report == nil {
+		return 0
+	}
+	return len(*b.report)
+}
+
+// truncate removes fields recorded after the first n.
+func (b *binder) truncate(n int) {
+	if b.report != nil {
+		*b.report = (*b.report)[:n]
+	}
+}
+
+// formatDefault returns the current value of a field in the same format
+// as Dump. It returns an empty string if the value can't be formatted.
+func formatDefault(rv reflect.Value, field reflect.StructField) string {
+	var (
+		s   string
+		err error
+	)
+	if enum, _ := fieldEnum(field); enum != nil {
+		s, err = dumpEnum(rv, enum)
+	} else if rv.Kind() == reflect.Slice {
+		s, err = dumpSlice(rv)
+	} else {
+		s, err = toString(rv)
+	}
+	if err != nil {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reportTarget struct {
+	Timeout   time.Duration
+	Host      string
+	Password  string `env:",secret"`
+	Token     string `env:"API_TOKEN,secret"`
+	Level     testLevel
+	Upstreams []Upstream
+}
+
+func TestWithReport(t *testing.T) {
+	var (
+		flags = Named("flags", MapEnv{"TIMEOUT": "5s"})
+		file  = Named(".env", MapEnv{
+			"TIMEOUT":                "10s",
+			"PASSWORD":               "hunter2",
+			"UPSTREAMS_0_HOST":       "a.example.com",
+			"UPSTREAMS_1_HOST":       "b.example.com",
+			"UPSTREAMS_1_BACKOFF_ID": "ignored",
+		})
+		e = Chain(flags, PrefixEnv(file, ""))
+		v = reportTarget{Host: "localhost", Token: "default", Level: levelWarn}
+		r Report
+	)
+
+	require.NoError(t, BindWith(&v, e, WithReport(&r)), "bind failed")
+
+	tests := []FieldSource{
+		{Field: "Timeout", Var: "TIMEOUT", Value: "5s", Source: "flags"},
+		{Field: "Host", Var: "HOST", Value: "localhost", Default: true},
+		{Field: "Password", Var: "PASSWORD", Value: Masked, Source: ".env", Secret: true},
+		{Field: "Token", Var: "API_TOKEN", Value: Masked, Default: true, Secret: true},
+		{Field: "Level", Var: "LEVEL", Value: "WARN2", Default: true},
+		{Field: "Upstreams[0].Host", Var: "UPSTREAMS_0_HOST", Value: "a.example.com", Source: ".env"},
+		{Field: "Upstreams[1].Port", Var: "UPSTREAMS_1_PORT", Value: "0", Default: true},
+	}
+	for _, td := range tests {
+		td := td
+		t.Run(td.Field, func(t *testing.T) {
+			fs, ok := r.Get(td.Field)
+			require.True(t, ok, "field not in report")
+			assert.Equal(t, td, fs, "unexpected source")
+		})
+	}
+
+	// fields of items beyond the last one aren't reported
+	_, ok := r.Get("Upstreams[2].Host")
+	assert.False(t, ok, "reported non-existent item")
+
+	// report is reset
+	require.NoError(t, BindWith(&v, MapEnv{}, WithReport(&r)), "bind failed")
+	fs, ok := r.Get("Timeout")
+	require.True(t, ok, "field not in report")
+	assert.Equal(t, FieldSource{Field: "Timeout", Var: "TIMEOUT", Value: "5s", Default: true}, fs, "unexpected source")
+	assert.Equal(t, 5, len(r), "unexpected report length")
+}
+
+func TestSourceName(t *testing.T) {
+	tests := []struct {
+		env Env
+		x   string
+	}{
+		{System, "environment"},
+		{Named("flags", MapEnv{}), "flags"},
+		{MapEnv{}, "env.MapEnv"},
+		{nil, ""},
+	}
+
+	for _, td := range tests {
+		assert.Equal(t, td.x, SourceName(td.env), "unexpected name")
+	}
+}
+
+func TestChain_LookupSource_nested(t *testing.T) {
+	inner := Named("inner", MapEnv{"KEY": "value"})
+	c := Chain(Named("outer", MapEnv{}), Chain(inner))
+	s, src, ok := c.LookupSource("KEY")
+	assert.True(t, ok, "key not found")
+	assert.Equal(t, "value", s, "unexpected value")
+	assert.Equal(t, "inner", SourceName(src), "unexpected source")
+}
+
+// Find out where each field's value came from.
+func ExampleWithReport() {
+	cfg := struct {
+		Host    string
+		Port    int
+		APIKey  string `env:"API_KEY,secret"`
+		Timeout time.Duration
+	}{Timeout: time.Second}
+
+	e := Chain(
+		Named("flags", MapEnv{"PORT": "8080"}),
+		Named(".env", MapEnv{"HOST": "example.com", "PORT": "80", "API_KEY": "xyz"}),
+	)
+
+	var r Report
+	if err := BindWith(&cfg, e, WithReport(&r)); err != nil {
+		panic(err)
+	}
+	fmt.Println(r)
+
+	// Output:
+	// Host: HOST=example.com (.env)
+	// Port: PORT=8080 (flags)
+	// APIKey: API_KEY=******** (.env)
+	// Timeout: TIMEOUT=1s (default)
+}
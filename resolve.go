@@ -0,0 +1,279 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Resolver retrieves the value a reference such as "file:///run/secrets/db"
+// points to. ref is the reference without the "scheme://" prefix.
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface.
+type ResolverFunc func(ref string) (string, error)
+
+// Resolve implements Resolver.
+func (fun ResolverFunc) Resolve(ref string) (string, error) { return fun(ref) }
+
+// ErrUnknownScheme is returned by a ResolvingEnv for a reference whose
+// scheme has no registered Resolver.
+type ErrUnknownScheme string
+
+// implements error.Error.
+func (err ErrUnknownScheme) Error() string {
+	return "no resolver registered for scheme " + string(err)
+}
+
+// Registered resolvers.
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]Resolver{
+		"file": FileResolver,
+	}
+)
+
+// RegisterResolver registers r to resolve references with the given scheme,
+// e.g. "vault". Passing a nil Resolver removes the scheme's resolver.
+//
+// The "file" scheme is registered by default. ExecResolver and
+// VaultResolver must be registered explicitly.
+func RegisterResolver(scheme string, r Resolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	if r == nil {
+		delete(resolvers, scheme)
+		return
+	}
+	resolvers[scheme] = r
+}
+
+// lookupResolver returns the Resolver registered for scheme, or nil.
+func lookupResolver(scheme string) Resolver {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	return resolvers[scheme]
+}
+
+// FileResolver reads the contents of a file, e.g. file:///run/secrets/db.
+// A trailing newline is removed.
+var FileResolver = ResolverFunc(func(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+})
+
+// ExecResolver runs a command and returns its output minus any trailing
+// newline, e.g. exec://pass show db. The command is split on whitespace and
+// not passed to a shell.
+//
+// ExecResolver runs any command named in the environment, so it is not
+// registered by default.
+var ExecResolver = ResolverFunc(func(command string) (string, error) {
+	argv := strings.Fields(command)
+	if len(argv) == 0 {
+		return "", errors.New("empty command")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return "", fmt.Errorf("%w: %s", err, s)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+})
+
+// VaultResolver reads secrets from a HashiCorp Vault KV version 2 secrets
+// engine. References have the form "<mount>/<path>#<field>", e.g.
+// vault://kv/app#password reads field "password" of secret "app" in the
+// engine mounted at "kv".
+//
+// Register it with the address of the server and a token:
+//
+//	env.RegisterResolver("vault", &env.VaultResolver{
+//		Addr:  os.Getenv("VAULT_ADDR"),
+//		Token: os.Getenv("VAULT_TOKEN"),
+//	})
+type VaultResolver struct {
+	Addr   string       // URL of Vault server, e.g. https://vault:8200
+	Token  string       // Vault token
+	Client *http.Client // HTTP client; http.DefaultClient is used if nil
+}
+
+// Resolve implements Resolver.
+func (vr *VaultResolver) Resolve(ref string) (string, error) {
+	i := strings.LastIndex(ref, "#")
+	j := strings.Index(ref, "/")
+	if i < 0 || j < 1 || j > i {
+		return "", fmt.Errorf("invalid vault reference %q (expected mount/path#field)", ref)
+	}
+	var (
+		mount, path, field = ref[:j], ref[j+1 : i], ref[i+1:]
+		u                  = strings.TrimRight(vr.Addr, "/") + "/v1/" + mount + "/data/" + path
+		client             = vr.Client
+	)
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", vr.Token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault: %s", resp.Status)
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	v, ok := secret.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("vault: no field %q in secret %s/%s", field, mount, path)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// ResolvingEnv returns an Env that reads values from env and resolves
+// those that are references, i.e. have the form "scheme://ref", via the
+// Resolver registered for the scheme with RegisterResolver.
+//
+// Resolving a reference whose scheme has no registered Resolver fails with
+// ErrUnknownScheme, so a misspelt scheme isn't mistaken for a value. Values
+// with the schemes listed in passthrough are returned as is. Pass "http"
+// and "https" if variables may contain URLs:
+//
+//	e := env.ResolvingEnv(env.System, "http", "https")
+//
+// Resolved values are cached, so each reference is only resolved once.
+// Bind returns an error if a reference can't be resolved (see FallibleEnv).
+func ResolvingEnv(env Env, passthrough ...string) Env {
+	return &resolvingEnv{
+		env:         env,
+		passthrough: passthrough,
+		cache:       map[string]*cachedValue{},
+	}
+}
+
+// resolvingEnv implements ResolvingEnv.
+type resolvingEnv struct {
+	env         Env
+	passthrough []string
+
+	mu    sync.Mutex
+	cache map[string]*cachedValue // reference -> value
+}
+
+// cachedValue is the resolved value of a reference. Its lock is held while
+// the reference is resolved, so each reference is only resolved once,
+// without blocking lookups of other references.
+type cachedValue struct {
+	mu    sync.Mutex
+	value string
+	ok    bool // whether value has been resolved
+}
+
+// Lookup implements Env.
+func (re *resolvingEnv) Lookup(key string) (string, bool) {
+	s, ok, err := re.LookupErr(key)
+	if err != nil {
+		return "", false
+	}
+	return s, ok
+}
+
+// LookupErr implements FallibleEnv.
+func (re *resolvingEnv) LookupErr(key string) (string, bool, error) {
+	s, _, ok, err := lookupSource(re.env, key)
+	if err != nil || !ok {
+		return "", ok, err
+	}
+	if s, err = re.resolve(s); err != nil {
+		return "", false, err
+	}
+	return s, true, nil
+}
+
+// Keys implements Lister.
+func (re *resolvingEnv) Keys() []string { return Keys(re.env, "") }
+
+// Name implements Namer.
+func (re *resolvingEnv) Name() string { return SourceName(re.env) }
+
+// resolve returns the value of reference s, or s if it isn't a reference.
+func (re *resolvingEnv) resolve(s string) (string, error) {
+	i := strings.Index(s, "://")
+	if i < 1 || !isName(s[:i]) {
+		return s, nil
+	}
+	scheme, ref := s[:i], s[i+3:]
+
+	if re.passes(scheme) {
+		return s, nil
+	}
+	r := lookupResolver(scheme)
+	if r == nil {
+		return "", ErrUnknownScheme(scheme)
+	}
+
+	re.mu.Lock()
+	c, ok := re.cache[s]
+	if !ok {
+		c = &cachedValue{}
+		re.cache[s] = c
+	}
+	re.mu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ok {
+		return c.value, nil
+	}
+	v, err := r.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", s, err)
+	}
+	c.value, c.ok = v, true
+	return v, nil
+}
+
+// passes returns true if values with scheme should be returned as is.
+func (re *resolvingEnv) passes(scheme string) bool {
+	for _, s := range re.passthrough {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vault stand-in serving secret kv/app.
+func testVault() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s3cr3t" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/app" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"data": {"password": "hunter2", "port": 5432}, "metadata": {}}}`)
+	}))
+}
+
+func TestVaultResolver(t *testing.T) {
+	ts := testVault()
+	defer ts.Close()
+
+	vr := &VaultResolver{Addr: ts.URL + "/", Token: "s3cr3t"}
+	tests := []struct {
+		ref, x, err string
+	}{
+		{"kv/app#password", "hunter2", ""},
+		{"kv/app#port", "5432", ""},
+		{"kv/app#user", "", `vault: no field "user" in secret kv/app`},
+		{"kv/other#password", "", "vault: 404 Not Found"},
+		{"kv/app", "", `invalid vault reference "kv/app" (expected mount/path#field)`},
+		{"app#password", "", `invalid vault reference "app#password" (expected mount/path#field)`},
+	}
+	for _, td := range tests {
+		td := td
+		t.Run(td.ref, func(t *testing.T) {
+			s, err := vr.Resolve(td.ref)
+			if td.err != "" {
+				assert.EqualError(t, err, td.err, "unexpected error")
+				return
+			}
+			require.NoError(t, err, "resolve failed")
+			assert.Equal(t, td.x, s, "unexpected value")
+		})
+	}
+
+	vr.Token = "wrong"
+	_, err := vr.Resolve("kv/app#password")
+	assert.EqualError(t, err, "vault: 403 Forbidden", "unexpected error")
+}
+
+func TestExecResolver(t *testing.T) {
+	// other tests clear the environment, including PATH
+	var echo string
+	for _, path := range []string{"/bin/echo", "/usr/bin/echo"} {
+		if _, err := os.Stat(path); err == nil {
+			echo = path
+			break
+		}
+	}
+	if echo == "" {
+		t.Skip("echo not found")
+	}
+	s, err := ExecResolver.Resolve(echo + " hello  world")
+	require.NoError(t, err, "resolve failed")
+	assert.Equal(t, "hello world", s, "unexpected value")
+
+	_, err = ExecResolver.Resolve("")
+	assert.Error(t, err, "accepted empty command")
+	_, err = ExecResolver.Resolve("/nonexistent/command")
+	assert.Error(t, err, "accepted invalid command")
+}
+
+func TestResolvingEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "env-")
+	require.NoError(t, err, "create tempdir")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "db")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hunter2\n"), 0600), "write file")
+
+	ts := testVault()
+	defer ts.Close()
+
+	var calls int
+	RegisterResolver("vault", &VaultResolver{Addr: ts.URL, Token: "s3cr3t"})
+	RegisterResolver("count", ResolverFunc(func(ref string) (string, error) {
+		calls++
+		return strings.ToUpper(ref), nil
+	}))
+	defer RegisterResolver("vault", nil)
+	defer RegisterResolver("count", nil)
+
+	base := Named("env", MapEnv{
+		"DB_PASS":   "file://" + path,
+		"API_KEY":   "vault://kv/app#password",
+		"URL":       "https://example.com",
+		"NAME":      "count://bob",
+		"COMMAND":   "exec://cat /etc/passwd",
+		"MISSING":   "file://" + filepath.Join(dir, "missing"),
+		"PLAIN":     "value",
+		"NOTSCHEME": "a b://c",
+	})
+	e := ResolvingEnv(base, "https")
+
+	r := New(e)
+	assert.Equal(t, "hunter2", r.Get("DB_PASS"), "unexpected DB_PASS")
+	assert.Equal(t, "hunter2", r.Get("API_KEY"), "unexpected API_KEY")
+	assert.Equal(t, "https://example.com", r.Get("URL"), "unexpected URL")
+	assert.Equal(t, "", r.Get("COMMAND"), "unexpected COMMAND")
+	assert.Equal(t, "value", r.Get("PLAIN"), "unexpected PLAIN")
+	assert.Equal(t, "a b://c", r.Get("NOTSCHEME"), "unexpected NOTSCHEME")
+	assert.Equal(t, "default", r.Get("MISSING", "default"), "unexpected MISSING")
+	assert.Equal(t, "env", SourceName(e), "unexpected name")
+	assert.Equal(t, len(base.(Lister).Keys()), len(Keys(e, "")), "unexpected keys")
+
+	// cached
+	assert.Equal(t, "BOB", r.Get("NAME"), "unexpected NAME")
+	assert.Equal(t, "BOB", r.Get("NAME"), "unexpected NAME")
+	assert.Equal(t, 1, calls, "value not cached")
+
+	// a slow lookup doesn't block other references
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	RegisterResolver("slow", ResolverFunc(func(ref string) (string, error) {
+		close(started)
+		<-release
+		return ref, nil
+	}))
+	defer RegisterResolver("slow", nil)
+	slow := ResolvingEnv(MapEnv{"SLOW": "slow://x", "NAME": "count://bob"})
+	done := make(chan string)
+	go func() {
+		s, _ := slow.Lookup("SLOW")
+		done <- s
+	}()
+	<-started
+	s, _ := slow.Lookup("NAME")
+	assert.Equal(t, "BOB", s, "unexpected NAME")
+	close(release)
+	assert.Equal(t, "x", <-done, "unexpected SLOW")
+
+	// Bind reports errors
+	v := struct {
+		DBPass  Secret `env:"DB_PASS"`
+		Missing string
+	}{}
+	err = Bind(&v, e)
+	require.Error(t, err, "resolved missing file")
+	assert.True(t, strings.HasPrefix(err.Error(), "MISSING: resolve file://"), "unexpected error: %v", err)
+	assert.True(t, errors.Is(err, os.ErrNotExist), "unexpected error: %v", err)
+	assert.Equal(t, Secret("hunter2"), v.DBPass, "unexpected DBPass")
+
+	// unregistered schemes fail unless passed through
+	w := struct {
+		DBPass  string `env:"DB_PASS"`
+		Command string
+	}{}
+	err = Bind(&w, e)
+	assert.EqualError(t, err, "COMMAND: no resolver registered for scheme exec", "unexpected error")
+	assert.True(t, errors.Is(err, ErrUnknownScheme("exec")), "unexpected error: %v", err)
+
+	w2 := struct{ Password string }{}
+	err = Bind(&w2, ResolvingEnv(MapEnv{"PASSWORD": "vualt://kv/app#pw"}))
+	assert.EqualError(t, err, "PASSWORD: no resolver registered for scheme vualt", "unexpected error")
+	assert.Equal(t, "", w2.Password, "unexpected Password")
+
+	err = Bind(&w2, ResolvingEnv(MapEnv{"PASSWORD": "https://example.com"}))
+	assert.EqualError(t, err, "PASSWORD: no resolver registered for scheme https", "unexpected error")
+
+	e = ResolvingEnv(base, "https", "exec")
+	require.NoError(t, Bind(&w, e), "bind failed")
+	assert.Equal(t, "hunter2", w.DBPass, "unexpected DBPass")
+	assert.Equal(t, "exec://cat /etc/passwd", w.Command, "unexpected Command")
+}
+
+// Read secrets from files referenced by variables.
+func ExampleResolvingEnv() {
+	dir, err := ioutil.TempDir("", "example-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "db")
+	if err := ioutil.WriteFile(path, []byte("hunter2\n"), 0600); err != nil {
+		panic(err)
+	}
+
+	_ = os.Setenv("DB_PASS", "file://"+path)
+
+	cfg := struct {
+		DBPass Secret `env:"DB_PASS"`
+	}{}
+	if err := Bind(&cfg, ResolvingEnv(System)); err != nil {
+		panic(err)
+	}
+	fmt.Println(string(cfg.DBPass))
+
+	// Output:
+	// hunter2
+
+	os.Clearenv()
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"reflect"
+)
+
+// Secret is a string that isn't revealed when printed. Its String and
+// GoString methods return Masked instead of the value, so passing a
+// Secret to fmt or log doesn't leak it. Convert it to a string to
+// retrieve the value.
+//
+// Fields of type Secret are treated like fields tagged `env:",secret"`,
+// but Dump and Export write the real value unless the MaskSecrets
+// option is given.
+type Secret string
+
+// String implements fmt.Stringer.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return Masked
+}
+
+// GoString implements fmt.GoStringer.
+func (s Secret) GoString() string {
+	return `env.Secret("` + s.String() + `")`
+}
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// MaskSecrets makes Dump replace the values of secret fields with Masked.
+// Secret fields are fields of type Secret and fields tagged
+// `env:",secret"`. Export ignores this option.
+var MaskSecrets DumpOption = func(d *dumper) { d.mask = true }
+
+// ParseError is returned by the Lookup* functions if a value is invalid,
+// and by Bind if the value of a secret field is invalid. Unlike the errors
+// Bind returns for other fields, the message of a ParseError for a secret
+// field never contains the value.
+type ParseError struct {
+	Var string // name of the variable
+	Err error  // the parse error (a generic error for secret fields)
+}
+
+// Error implements error.
+func (err *ParseError) Error() string {
+	return "invalid value for " + err.Var + ": " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *ParseError) Unwrap() error { return err.Err }
+
+// secretError returns the error for an invalid value of secret field
+// field. Parse errors often quote (parts of) the value, so the parser's
+// error is discarded, and only the field's type is named.
+func secretError(field reflect.StructField) error {
+	return redactedError("not a valid " + field.Type.String() + " (value redacted)")
+}
+
+// error that replaces one that may contain a secret.
+type redactedError string
+
+func (err redactedError) Error() string { return string(err) }
+
+// isSecret returns true if field is tagged `env:",secret"` or its type is
+// Secret (or a pointer to or slice of Secret).
+func isSecret(field reflect.StructField) bool {
+	if hasOption(field, "secret") {
+		return true
+	}
+	typ := field.Type
+	if typ == nil {
+		return false
+	}
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	return typ == secretType
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type secretTarget struct {
+	User    string
+	Token   Secret
+	TokenP  *Secret `env:"TOKEN_P"`
+	Pin     int     `env:",secret"`
+	Tokens  []Secret
+	Comment string
+}
+
+func TestSecret(t *testing.T) {
+	s := Secret("hunter2")
+	assert.Equal(t, Masked, s.String(), "unexpected String")
+	assert.Equal(t, Masked, fmt.Sprint(s), "unexpected %v")
+	assert.Equal(t, `"********"`, fmt.Sprintf("%q", s), "unexpected %q")
+	assert.Equal(t, `env.Secret("********")`, fmt.Sprintf("%#v", s), "unexpected %#v")
+	assert.Equal(t, "hunter2", string(s), "unexpected value")
+	assert.Equal(t, "", Secret("").String(), "unexpected empty String")
+
+	v := struct{ Token Secret }{s}
+	assert.Equal(t, "{********}", fmt.Sprint(v), "secret leaked in struct")
+}
+
+func TestBind_secret(t *testing.T) {
+	e := MapEnv{
+		"USER":    "bob",
+		"TOKEN":   "abc",
+		"TOKEN_P": "def",
+		"PIN":     "1234",
+		"TOKENS":  "a,b",
+	}
+	var v secretTarget
+	require.NoError(t, Bind(&v, e), "bind failed")
+	def := Secret("def")
+	assert.Equal(t, secretTarget{"bob", "abc", &def, 1234, []Secret{"a", "b"}, ""}, v, "unexpected result")
+
+	// value isn't echoed in error
+	err := Bind(&v, MapEnv{"PIN": "12x4"})
+	require.Error(t, err, "accepted invalid PIN")
+	assert.NotContains(t, err.Error(), "12x4", "secret leaked in error")
+	assert.EqualError(t, err, "invalid value for PIN: not a valid int (value redacted)", "unexpected error")
+	pe, ok := err.(*ParseError)
+	require.True(t, ok, "not a *ParseError")
+	assert.Equal(t, "PIN", pe.Var, "unexpected Var")
+
+	tests := []struct {
+		name string
+		v    interface{}
+		env  MapEnv
+		leak string
+	}{
+		{"slice item", &struct {
+			Pins []int `env:",secret"`
+		}{}, MapEnv{"PINS": "1234,hunter2"}, "hunter2"},
+		{"quoted value", &struct {
+			Pin int `env:",secret"`
+		}{}, MapEnv{"PIN": `x"y`}, `x\"y`},
+		{"control bytes", &struct {
+			Pin int `env:",secret"`
+		}{}, MapEnv{"PIN": "x\ty"}, `x\ty`},
+		{"partial value", &struct {
+			Pattern *regexp.Regexp `env:",secret"`
+		}{}, MapEnv{"PATTERN": "hunter2(x"}, "hunter2"},
+	}
+	for _, td := range tests {
+		td := td
+		t.Run(td.name, func(t *testing.T) {
+			err := Bind(td.v, td.env)
+			require.Error(t, err, "accepted invalid value")
+			assert.NotContains(t, err.Error(), td.leak, "secret leaked in error")
+			_, ok := err.(*ParseError)
+			assert.True(t, ok, "not a *ParseError")
+		})
+	}
+}
+
+func TestDump_secret(t *testing.T) {
+	def := Secret("def")
+	v := secretTarget{"bob", "abc", &def, 1234, []Secret{"a", "b"}, ""}
+
+	m, err := Dump(v)
+	require.NoError(t, err, "dump failed")
+	assert.Equal(t, map[string]string{
+		"USER":    "bob",
+		"TOKEN":   "abc",
+		"TOKEN_P": "def",
+		"PIN":     "1234",
+		"TOKENS":  "a,b",
+		"COMMENT": "",
+	}, m, "unexpected result")
+
+	m, err = Dump(v, MaskSecrets)
+	require.NoError(t, err, "dump failed")
+	assert.Equal(t, map[string]string{
+		"USER":    "bob",
+		"TOKEN":   Masked,
+		"TOKEN_P": Masked,
+		"PIN":     Masked,
+		"TOKENS":  Masked,
+		"COMMENT": "",
+	}, m, "unexpected result")
+
+	// Export writes the real values
+	os.Clearenv()
+	defer os.Clearenv()
+	require.NoError(t, Export(v, MaskSecrets), "export failed")
+	assert.Equal(t, "abc", os.Getenv("TOKEN"), "unexpected TOKEN")
+	assert.Equal(t, "1234", os.Getenv("PIN"), "unexpected PIN")
+}
+
+func TestSecret_reports(t *testing.T) {
+	var (
+		v = secretTarget{Token: "default"}
+		e = MapEnv{"TOKEN_P": "def", "TOKENS": "a,b"}
+		r Report
+	)
+	require.NoError(t, BindWith(&v, e, WithReport(&r)), "bind failed")
+	for _, fs := range r {
+		if fs.Field == "User" || fs.Field == "Comment" {
+			assert.False(t, fs.Secret, "not a secret")
+			continue
+		}
+		assert.True(t, fs.Secret, "not a secret")
+		assert.Equal(t, Masked, fs.Value, "unexpected value")
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Usage(&buf, v, UsageEnv(e)), "usage failed")
+	assert.NotContains(t, buf.String(), "def", "secret leaked in usage")
+	assert.NotContains(t, buf.String(), "a,b", "secret leaked in usage")
+
+	data, err := Example(v)
+	require.NoError(t, err, "example failed")
+	assert.NotContains(t, string(data), "default", "secret leaked in example")
+}
+
+// Prevent secrets from being logged.
+func ExampleSecret() {
+	_ = os.Setenv("API_TOKEN", "hunter2")
+
+	cfg := struct {
+		APIToken Secret
+	}{}
+	if err := Bind(&cfg); err != nil {
+		panic(err)
+	}
+
+	fmt.Println(cfg.APIToken)
+	fmt.Println(string(cfg.APIToken))
+
+	vars, _ := Dump(cfg, MaskSecrets)
+	fmt.Println(vars)
+
+	// Output:
+	// ********
+	// hunter2
+	// map[API_TOKEN:********]
+
+	os.Clearenv()
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"sort"
+	"strings"
+)
+
+// Strict makes BindWith check for variables that start with prefix but
+// do not correspond to any field of the struct, e.g. misspelt names.
+// If there are any such variables, BindWith returns an *UnknownVarsError.
+//
+// Unknown variables are only detected if the Env implements Lister.
+//
+// The struct is fully populated before the error is returned, so you
+// can treat an *UnknownVarsError as a warning instead of a failure.
+func Strict(prefix string) BindOption {
+	return func(b *binder) {
+		b.strict = true
+		b.strictPrefix = prefix
+	}
+}
+
+// UnknownVar is a variable that was not bound to any field.
+type UnknownVar struct {
+	Name       string // name of the variable
+	Suggestion string // name of the most similar known variable (if any)
+}
+
+// UnknownVarsError is returned by BindWith in strict mode if the Env
+// contains unknown variables.
+type UnknownVarsError struct {
+	Vars []UnknownVar
+}
+
+// Error implements error.
+func (err *UnknownVarsError) Error() string {
+	s := make([]string, len(err.Vars))
+	for i, v := range err.Vars {
+		s[i] = v.Name
+		if v.Suggestion != "" {
+			s[i] += " (did you mean " + v.Suggestion + "?)"
+		}
+	}
+	return "unknown variables: " + strings.Join(s, ", ")
+}
+
+// checkUnknown returns an *UnknownVarsError if in strict mode and the Env
+// contains variables with the strict prefix that were not looked up.
+func (b *binder) checkUnknown() error {
+	if !b.strict {
+		return nil
+	}
+
+	var known []string
+	for k := range b.seen {
+		if strings.HasPrefix(k, b.strictPrefix) {
+			known = append(known, k)
+		}
+	}
+	sort.Strings(known)
+
+	var unknown []UnknownVar
+	for _, k := range Keys(b.env, b.strictPrefix) {
+		if !b.seen[k] {
+			unknown = append(unknown, UnknownVar{Name: k, Suggestion: suggest(k, known)})
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+	return &UnknownVarsError{Vars: unknown}
+}
+
+// suggest returns the name in candidates most similar to name, or an
+// empty string if none is similar enough.
+func suggest(name string, candidates []string) string {
+	var (
+		best  string
+		limit = len(name)/3 + 1
+	)
+	for _, s := range candidates {
+		if d := editDistance(name, s); d < limit {
+			best, limit = s, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(n ...int) int {
+	m := n[0]
+	for _, i := range n[1:] {
+		if i < m {
+			m = i
+		}
+	}
+	return m
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrict(t *testing.T) {
+	type config struct {
+		DatabaseURL string              `env:"APP_DATABASE_URL"`
+		Port        int                 `env:"APP_PORT"`
+		Debug       bool                `env:"APP_DEBUG"`
+		Upstreams   []Upstream          `env:"APP_UPSTREAMS"`
+		DB          map[string]DBConfig `env:"APP_DB"`
+	}
+
+	tests := []struct {
+		name string
+		env  MapEnv
+		x    []UnknownVar
+	}{
+		{"empty", MapEnv{}, nil},
+		{"known", MapEnv{
+			"APP_DATABASE_URL":       "postgres://localhost",
+			"APP_PORT":               "80",
+			"APP_DEBUG":              "",
+			"APP_UPSTREAMS_0_HOST":   "localhost",
+			"APP_DB_PRIMARY_HOST":    "localhost",
+			"APP_DB_PRIMARY_PORT":    "5432",
+			"OTHER_VARIABLE":         "ignored",
+			"APPLICATION_IS_IGNORED": "ignored",
+		}, nil},
+		{"typos", MapEnv{
+			"APP_DATABSE_URL":      "postgres://localhost",
+			"APP_PROT":             "80",
+			"APP_UPSTREAMS_0_HSOT": "localhost",
+			"APP_SOMETHING_ELSE":   "",
+		}, []UnknownVar{
+			{"APP_DATABSE_URL", "APP_DATABASE_URL"},
+			{"APP_PROT", "APP_PORT"},
+			{"APP_SOMETHING_ELSE", ""},
+			{"APP_UPSTREAMS_0_HSOT", "APP_UPSTREAMS_0_HOST"},
+		}},
+	}
+
+	for _, td := range tests {
+		td := td
+		t.Run(td.name, func(t *testing.T) {
+			var c config
+			err := BindWith(&c, td.env, Strict("APP_"))
+			if td.x == nil {
+				assert.NoError(t, err, "unexpected error")
+				return
+			}
+
+			var uv *UnknownVarsError
+			require.True(t, errors.As(err, &uv), "unexpected error type")
+			assert.Equal(t, td.x, uv.Vars, "unexpected unknown variables")
+		})
+	}
+
+	// struct is still populated
+	var c config
+	err := BindWith(&c, MapEnv{"APP_PORT": "80", "APP_PROT": "81"}, Strict("APP_"))
+	assert.EqualError(t, err, "unknown variables: APP_PROT (did you mean APP_PORT?)", "unexpected error")
+	assert.Equal(t, 80, c.Port, "unexpected Port")
+
+	// invalid values take precedence
+	err = BindWith(&c, MapEnv{"APP_PORT": "eighty", "APP_PROT": "81"}, Strict("APP_"))
+	assert.Error(t, err, "accepted invalid value")
+	assert.False(t, errors.As(err, new(*UnknownVarsError)), "unexpected error type")
+
+	// Env isn't a Lister
+	e := MapEnv{"APP_PROT": "81"}
+	assert.NoError(t, BindWith(&c, funcEnv(e.Lookup), Strict("APP_")), "unexpected error")
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		x    int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abc", "abc", 0},
+		{"APP_PORT", "APP_PROT", 2},
+		{"APP_DATABSE_URL", "APP_DATABASE_URL", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, td := range tests {
+		assert.Equal(t, td.x, editDistance(td.a, td.b), "unexpected distance")
+		assert.Equal(t, td.x, editDistance(td.b, td.a), "unexpected distance")
+	}
+}
+
+// Strict mode reports unknown variables with the given prefix.
+func ExampleStrict() {
+	type config struct {
+		DatabaseURL string `env:"APP_DATABASE_URL"`
+		Port        int    `env:"APP_PORT"`
+	}
+
+	e := MapEnv{
+		"APP_DATABSE_URL": "postgres://localhost/app",
+		"APP_PORT":        "8080",
+	}
+
+	var c config
+	err := BindWith(&c, e, Strict("APP_"))
+	var uv *UnknownVarsError
+	if errors.As(err, &uv) {
+		// treat as a warning
+		fmt.Println(err)
+	} else if err != nil {
+		panic(err)
+	}
+	fmt.Println(c.Port)
+
+	// Output:
+	// unknown variables: APP_DATABSE_URL (did you mean APP_DATABASE_URL?)
+	// 8080
+}
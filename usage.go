@@ -0,0 +1,219 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// VarInfo describes a variable read by Bind. Usage returns a list of
+// VarInfo in JSON mode.
+type VarInfo struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Default     string `json:"default"`
+	Required    bool   `json:"required"`
+	Secret      bool   `json:"secret"`
+	Value       string `json:"value"`
+	Description string `json:"description"`
+}
+
+// UsageOption is a configuration option to Usage.
+type UsageOption func(u *usage)
+
+// Output modes for Usage. The default is a plain-text table.
+var (
+	// UsageMarkdown makes Usage write a Markdown table.
+	UsageMarkdown UsageOption = func(u *usage) { u.mode = usageMarkdown }
+	// UsageJSON makes Usage write a JSON array of VarInfo.
+	UsageJSON UsageOption = func(u *usage) { u.mode = usageJSON }
+)
+
+// UsageEnv specifies the Env that Usage reads current values from.
+// The default is the Env set with SetDefault, i.e. the program's
+// environment unless changed.
+func UsageEnv(e Env) UsageOption {
+	return func(u *usage) { u.env = e }
+}
+
+type usageMode int
+
+const (
+	usageText usageMode = iota
+	usageMarkdown
+	usageJSON
+)
+
+// usage generates the list of variables for Usage.
+type usage struct {
+	mode usageMode
+	env  Env
+	vars []VarInfo
+}
+
+// Usage writes a table of the variables that Bind reads into struct v to w.
+// For each variable, it lists the variable's name, Go type, default value
+// (the field's current value), whether it's required, its current value in
+// the environment and the field's `desc:"..."` tag.
+//
+// Variable names are generated exactly as by Bind. Items of slices and maps
+// of structs are shown as KEY_<n>_FIELD and KEY_<name>_FIELD respectively.
+// The default and current values of secret fields (fields of type Secret
+// or tagged `env:",secret"`) are masked.
+func Usage(w io.Writer, v interface{}, opt ...UsageOption) error {
+	u := &usage{env: defaultEnvironment()}
+	for _, o := range opt {
+		o(u)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+	u.walk(rv, rv.Type(), "", map[reflect.Type]bool{})
+
+	switch u.mode {
+	case usageMarkdown:
+		return u.writeMarkdown(w)
+	case usageJSON:
+		return u.writeJSON(w)
+	}
+	return u.writeText(w)
+}
+
+// walk adds the variables of struct type typ to u.vars. rv is a value of
+// typ, or an invalid Value for items of slices and maps.
+func (u *usage) walk(rv reflect.Value, typ reflect.Type, prefix string, seen map[reflect.Type]bool) {
+	walkVars(rv, typ, prefix, seen, func(key string, field reflect.StructField, rv reflect.Value, kind varKind) {
+		switch kind {
+		case sliceVar:
+			u.add(key+"_COUNT", "int", field, reflect.Value{})
+			u.vars[len(u.vars)-1].Description = "Number of items."
+			u.walk(reflect.Value{}, structType(field.Type.Elem()), key+"_<n>_", seen)
+		case mapVar:
+			u.walk(reflect.Value{}, structType(field.Type.Elem()), key+"_<name>_", seen)
+		default:
+			u.add(key, fieldType(field), field, rv)
+		}
+	})
+}
+
+// add a variable to u.vars.
+func (u *usage) add(key, typ string, field reflect.StructField, rv reflect.Value) {
+	vi := VarInfo{
+		Name:        key,
+		Type:        typ,
+		Required:    hasOption(field, "required"),
+		Secret:      isSecret(field),
+		Description: field.Tag.Get("desc"),
+	}
+	if rv.IsValid() {
+		vi.Default = formatDefault(rv, field)
+	}
+	vi.Value, _ = u.env.Lookup(key)
+	if vi.Secret {
+		if vi.Default != "" {
+			vi.Default = Masked
+		}
+		if vi.Value != "" {
+			vi.Value = Masked
+		}
+	}
+	u.vars = append(u.vars, vi)
+}
+
+func (u *usage) writeText(w io.Writer) error {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VARIABLE\tTYPE\tDEFAULT\tREQUIRED\tVALUE\tDESCRIPTION")
+	for _, vi := range u.vars {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", vi.Name, vi.Type,
+			oneLine(vi.Default), yesNo(vi.Required), oneLine(vi.Value), vi.Description)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// remove padding of empty trailing cells
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i, s := range lines {
+		lines[i] = strings.TrimRight(s, " ")
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func (u *usage) writeMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("| Variable | Type | Default | Required | Value | Description |\n")
+	sb.WriteString("|----------|------|---------|----------|-------|-------------|\n")
+	for _, vi := range u.vars {
+		cells := []string{
+			"`" + vi.Name + "`",
+			markdownCell(vi.Type),
+			markdownCode(vi.Default),
+			yesNo(vi.Required),
+			markdownCode(vi.Value),
+			markdownCell(vi.Description),
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (u *usage) writeJSON(w io.Writer) error {
+	vars := u.vars
+	if vars == nil {
+		vars = []VarInfo{}
+	}
+	data, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// structType returns the struct type of a struct or pointer to a struct.
+func structType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		return typ.Elem()
+	}
+	return typ
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// oneLine escapes line breaks, so a value fits in a table cell.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", `\r`, "\n", `\n`).Replace(s)
+}
+
+// markdownCell escapes s for use in a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.Replace(oneLine(s), "|", `\|`, -1)
+}
+
+// markdownCode formats a non-empty value as code for a Markdown table cell.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownCell(s) + "`"
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2020 Dean Jackson <deanishe@deanishe.net>
+// MIT Licence applies http://opensource.org/licenses/MIT
+
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type usageConfig struct {
+	Host     string        `env:",required" desc:"Address to listen on."`
+	Timeout  time.Duration `desc:"Request timeout | in seconds."`
+	Password string        `env:",secret"`
+	DB       *DBConfig
+	Servers  map[string]Upstream
+}
+
+var usageEnv = MapEnv{
+	"HOST":     "example.com",
+	"PASSWORD": "hunter2",
+}
+
+func usageValue() usageConfig {
+	return usageConfig{Host: "localhost", Timeout: time.Second, Password: "x", DB: &DBConfig{Port: 5432}}
+}
+
+func TestUsage_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Usage(&buf, usageValue(), UsageEnv(usageEnv), UsageJSON), "usage failed")
+
+	var vars []VarInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &vars), "invalid JSON")
+
+	x := []VarInfo{
+		{Name: "HOST", Type: "string", Default: "localhost", Required: true, Value: "example.com", Description: "Address to listen on."},
+		{Name: "TIMEOUT", Type: "time.Duration", Default: "1s", Description: "Request timeout | in seconds."},
+		{Name: "PASSWORD", Type: "string", Default: Masked, Secret: true, Value: Masked},
+		{Name: "HOST", Type: "string", Default: ""},
+		{Name: "PORT", Type: "int", Default: "5432", Value: ""},
+		{Name: "REPLICAS_COUNT", Type: "int", Description: "Number of items."},
+		{Name: "REPLICAS_<n>_HOST", Type: "string"},
+		{Name: "REPLICAS_<n>_PORT", Type: "int"},
+		{Name: "REPLICAS_<n>_TAGS", Type: "[]string"},
+		{Name: "REPLICAS_<n>_NESTED_STRING", Type: "string"},
+		{Name: "REPLICAS_<n>_NESTED_NUM", Type: "int"},
+		{Name: "SERVERS_<name>_HOST", Type: "string"},
+		{Name: "SERVERS_<name>_PORT", Type: "int"},
+		{Name: "SERVERS_<name>_TAGS", Type: "[]string"},
+		{Name: "SERVERS_<name>_NESTED_STRING", Type: "string"},
+		{Name: "SERVERS_<name>_NESTED_NUM", Type: "int"},
+	}
+	// DB fields use the same names as top-level fields, as with Bind
+	x[3].Value = "example.com"
+	assert.Equal(t, x, vars, "unexpected result")
+
+	buf.Reset()
+	require.NoError(t, Usage(&buf, struct{}{}, UsageJSON), "usage failed")
+	assert.Equal(t, "[]\n", buf.String(), "unexpected result")
+}
+
+// Usage lists the variables Bind reads, including those of nil structs.
+func TestUsage_bind(t *testing.T) {
+	type inner struct {
+		Host string
+	}
+	v := struct {
+		DB      *inner
+		Ignored inner `env:"-"`
+	}{}
+
+	var buf bytes.Buffer
+	require.NoError(t, Usage(&buf, v, UsageJSON), "usage failed")
+	var vars []VarInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &vars), "invalid JSON")
+	assert.Equal(t, []VarInfo{{Name: "HOST", Type: "string"}}, vars, "unexpected result")
+
+	require.NoError(t, Bind(&v, MapEnv{"HOST": "x"}), "bind failed")
+	assert.Equal(t, &inner{Host: "x"}, v.DB, "unexpected DB")
+	assert.Equal(t, inner{}, v.Ignored, "unexpected Ignored")
+}
+
+func TestUsage_text(t *testing.T) {
+	v := struct {
+		Host string `env:",required" desc:"Address to listen on."`
+		Tags []string
+	}{Host: "localhost", Tags: []string{"a", "b"}}
+
+	x := `VARIABLE  TYPE      DEFAULT    REQUIRED  VALUE  DESCRIPTION
+HOST      string    localhost  yes       x\ny   Address to listen on.
+TAGS      []string  a,b        no
+`
+	var buf bytes.Buffer
+	require.NoError(t, Usage(&buf, &v, UsageEnv(MapEnv{"HOST": "x\ny"})), "usage failed")
+	assert.Equal(t, x, buf.String(), "unexpected result")
+}
+
+func TestUsage_markdown(t *testing.T) {
+	v := struct {
+		Timeout  time.Duration `desc:"a | b"`
+		Password string        `env:",secret"`
+	}{Timeout: time.Second}
+
+	x := "| Variable | Type | Default | Required | Value | Description |\n" +
+		"|----------|------|---------|----------|-------|-------------|\n" +
+		"| `TIMEOUT` | time.Duration | `1s` | no |  | a \\| b |\n" +
+		"| `PASSWORD` | string |  | no | `********` |  |\n"
+
+	var buf bytes.Buffer
+	require.NoError(t, Usage(&buf, v, UsageMarkdown, UsageEnv(MapEnv{"PASSWORD": "x"})), "usage failed")
+	assert.Equal(t, x, buf.String(), "unexpected result")
+
+	assert.Equal(t, ErrNotStruct, Usage(&buf, "x"), "unexpected error")
+}
+
+// Print the variables a program understands.
+func ExampleUsage() {
+	cfg := struct {
+		Listen  string        `env:",required" desc:"Address to listen on."`
+		Timeout time.Duration `desc:"Request timeout."`
+		APIKey  string        `env:"API_KEY,secret"`
+	}{Timeout: time.Second * 5}
+
+	_ = os.Setenv("LISTEN", "localhost:8080")
+	_ = os.Setenv("API_KEY", "xyz")
+
+	if err := Usage(os.Stdout, cfg); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// VARIABLE  TYPE           DEFAULT  REQUIRED  VALUE           DESCRIPTION
+	// LISTEN    string                  yes       localhost:8080  Address to listen on.
+	// TIMEOUT   time.Duration  5s       no                        Request timeout.
+	// API_KEY   string                  no        ********
+
+	os.Clearenv()
+}